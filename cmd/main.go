@@ -1,12 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	_ "github.com/lib/pq"
 
 	"github.com/SerzhLimon/PaymentService/config"
+	"github.com/SerzhLimon/PaymentService/internal/metrics"
+	"github.com/SerzhLimon/PaymentService/internal/outbox"
+	"github.com/SerzhLimon/PaymentService/internal/tracing"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
 	serv "github.com/SerzhLimon/PaymentService/internal/transport"
+	uc "github.com/SerzhLimon/PaymentService/internal/usecase"
 	"github.com/SerzhLimon/PaymentService/pkg/postgres"
 	"github.com/SerzhLimon/PaymentService/pkg/postgres/migrations"
 )
@@ -24,6 +36,18 @@ func main() {
 	cfg := config.LoadConfig()
 	logrus.Debugf("Configuration loaded: %+v", cfg)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.ServiceName, cfg.Tracing.SampleRatio)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize tracing")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to flush traces")
+		}
+	}()
+
 	logrus.Info("Initializing PostgreSQL client...")
 	db, err := postgres.InitPostgresClient(cfg.Postgres)
 	if err != nil {
@@ -44,17 +68,110 @@ func main() {
 	logrus.Info("Migrations applied successfully")
 
 	logrus.Info("Initializing server...")
-	server := serv.NewServer(db)
+	server := serv.NewServer(db, cfg)
+	metrics.RegisterDB(db)
+	metrics.RegisterOutboxLag(server.Usecase.GetOutboxLag)
 	routes := serv.ApiHandleFunctions{
 		Server: *server,
 	}
 
+	if cfg.Auth.BootstrapKey != "" {
+		if err := server.Usecase.BootstrapAPIKey(context.Background(), cfg.Auth.BootstrapKey); err != nil {
+			logrus.WithError(err).Fatal("Failed to register bootstrap API key")
+		}
+	} else {
+		logrus.Warn("API_BOOTSTRAP_KEY is not set, API keys can only be created with an existing admin key")
+	}
+
 	logrus.Info("Setting up router...")
-	router := serv.NewRouter(routes)
+	router := serv.NewRouter(routes, cfg.HTTP)
 
-	logrus.Infof("Starting server on port %s...", ":8080")
-	if err := router.Run(":8080"); err != nil {
-		logrus.WithError(err).Fatal("Failed to start server")
+	httpServer := &http.Server{
+		Addr:         cfg.HTTP.Addr,
+		Handler:      router,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
 	}
-	logrus.Info("Server started successfully")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		uc.RunHoldExpiry(ctx, server.Usecase, cfg.Holds.ExpiryInterval)
+	}()
+
+	publisher, err := outbox.NewPublisher(cfg.Outbox.Publisher, cfg.Outbox.URL, cfg.Outbox.Timeout)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize outbox publisher")
+	}
+	outboxRetry := webhook.RetryPolicy{
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+		BaseDelay:   cfg.Outbox.BaseDelay,
+		MaxDelay:    cfg.Outbox.MaxDelay,
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		uc.RunOutboxRelay(ctx, server.Usecase, publisher, outboxRetry, cfg.Outbox.RelayInterval, cfg.Outbox.BatchSize)
+	}()
+
+	retry := webhook.RetryPolicy{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BaseDelay:   cfg.Webhooks.BaseDelay,
+		MaxDelay:    cfg.Webhooks.MaxDelay,
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		uc.RunWebhookDelivery(ctx, server.Usecase, webhook.NewClient(cfg.Webhooks.Timeout), retry,
+			cfg.Webhooks.DeliveryInterval, cfg.Webhooks.BatchSize)
+	}()
+
+	metricsServer := &http.Server{
+		Addr:         cfg.Metrics.Addr,
+		Handler:      metrics.Handler(),
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 2)
+	go func() {
+		logrus.Infof("Starting server on %s...", cfg.HTTP.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	go func() {
+		logrus.Infof("Serving metrics on %s...", cfg.Metrics.Addr)
+		if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		logrus.WithError(err).Error("Server stopped unexpectedly")
+	case <-ctx.Done():
+		logrus.Info("Shutdown signal received, draining in-flight requests...")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Error("Failed to drain in-flight requests before shutdown timeout")
+	}
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Error("Failed to stop metrics server")
+	}
+
+	logrus.Info("Waiting for background workers to stop...")
+	workers.Wait()
+	logrus.Info("Server stopped")
 }
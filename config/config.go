@@ -3,12 +3,53 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 const (
 	filepath = "config/config.env"
+
+	defaultHTTPAddr        = ":8080"
+	defaultReadTimeout     = 15 * time.Second
+	defaultWriteTimeout    = 15 * time.Second
+	defaultIdleTimeout     = 60 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
+	defaultRequestTimeout  = 10 * time.Second
+
+	defaultMetricsAddr = "localhost:9090"
+
+	defaultTxMaxAttempts = 5
+	defaultTxBaseDelay   = 10 * time.Millisecond
+	defaultTxMaxDelay    = 500 * time.Millisecond
+
+	defaultHoldExpiryInterval = time.Minute
+
+	defaultFXRatesFile = "config/fx_rates.json"
+	defaultFXQuoteTTL  = 30 * time.Second
+
+	defaultOutboxPublisher     = "log"
+	defaultOutboxRelayInterval = time.Second
+	defaultOutboxBatchSize     = 100
+	defaultOutboxTimeout       = 5 * time.Second
+	defaultOutboxMaxAttempts   = 10
+	defaultOutboxBaseDelay     = 5 * time.Second
+	defaultOutboxMaxDelay      = 10 * time.Minute
+
+	defaultWebhookTimeout          = 10 * time.Second
+	defaultWebhookMaxAttempts      = 8
+	defaultWebhookBaseDelay        = 10 * time.Second
+	defaultWebhookMaxDelay         = time.Hour
+	defaultWebhookDeliveryInterval = time.Second
+	defaultWebhookBatchSize        = 50
+
+	defaultJWKSRefresh = 10 * time.Minute
+
+	defaultTracingExporter    = "none"
+	defaultTracingServiceName = "payment-service"
+	defaultTracingSampleRatio = 1.0
 )
 
 type PostgresConfig struct {
@@ -20,8 +61,94 @@ type PostgresConfig struct {
 	Password string `json:"password"`
 }
 
+type TxRetryConfig struct {
+	MaxAttempts int           `json:"max_attempts"`
+	BaseDelay   time.Duration `json:"base_delay"`
+	MaxDelay    time.Duration `json:"max_delay"`
+}
+
+type HTTPConfig struct {
+	Addr            string        `json:"addr"`
+	ReadTimeout     time.Duration `json:"read_timeout"`
+	WriteTimeout    time.Duration `json:"write_timeout"`
+	IdleTimeout     time.Duration `json:"idle_timeout"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+	RequestTimeout  time.Duration `json:"request_timeout"`
+}
+
+// MetricsConfig sets the internal listener serving Prometheus metrics. It is
+// separate from the API so scrapes need no API key; keep it off public networks.
+type MetricsConfig struct {
+	Addr string `json:"addr"`
+}
+
+type HoldsConfig struct {
+	ExpiryInterval time.Duration `json:"expiry_interval"`
+}
+
+type FXConfig struct {
+	RatesFile string        `json:"rates_file"`
+	QuoteTTL  time.Duration `json:"quote_ttl"`
+}
+
+type OutboxConfig struct {
+	Publisher     string        `json:"publisher"`
+	URL           string        `json:"url"`
+	Timeout       time.Duration `json:"timeout"`
+	RelayInterval time.Duration `json:"relay_interval"`
+	BatchSize     int           `json:"batch_size"`
+	MaxAttempts   int           `json:"max_attempts"`
+	BaseDelay     time.Duration `json:"base_delay"`
+	MaxDelay      time.Duration `json:"max_delay"`
+}
+
+type WebhooksConfig struct {
+	Timeout          time.Duration `json:"timeout"`
+	MaxAttempts      int           `json:"max_attempts"`
+	BaseDelay        time.Duration `json:"base_delay"`
+	MaxDelay         time.Duration `json:"max_delay"`
+	DeliveryInterval time.Duration `json:"delivery_interval"`
+	BatchSize        int           `json:"batch_size"`
+}
+
+type AuthConfig struct {
+	BootstrapKey string `json:"-"`
+}
+
+// String keeps the bootstrap key out of logged configuration.
+func (a AuthConfig) String() string {
+	return "{BootstrapKey:<redacted>}"
+}
+
+// JWTConfig configures end-user bearer tokens. JWKS is a local file or an
+// http(s) URL; tokens are rejected unless it, Issuer and Audience are set.
+type JWTConfig struct {
+	Issuer      string        `json:"issuer"`
+	Audience    string        `json:"audience"`
+	JWKS        string        `json:"jwks"`
+	JWKSRefresh time.Duration `json:"jwks_refresh"`
+}
+
+// TracingConfig selects the span exporter: "none", "otlp" or "stdout". The
+// OTLP endpoint is read from the standard OTEL_EXPORTER_OTLP_* variables.
+type TracingConfig struct {
+	Exporter    string  `json:"exporter"`
+	ServiceName string  `json:"service_name"`
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
 type Config struct {
 	Postgres PostgresConfig `json:"postgres"`
+	TxRetry  TxRetryConfig  `json:"tx_retry"`
+	HTTP     HTTPConfig     `json:"http"`
+	Metrics  MetricsConfig  `json:"metrics"`
+	Holds    HoldsConfig    `json:"holds"`
+	FX       FXConfig       `json:"fx"`
+	Outbox   OutboxConfig   `json:"outbox"`
+	Webhooks WebhooksConfig `json:"webhooks"`
+	Auth     AuthConfig     `json:"auth"`
+	JWT      JWTConfig      `json:"jwt"`
+	Tracing  TracingConfig  `json:"tracing"`
 }
 
 func LoadConfig() Config {
@@ -39,10 +166,121 @@ func LoadConfig() Config {
 		Password: getEnv("POSTGRES_PASSWORD"),
 	}
 
+	config.TxRetry = TxRetryConfig{
+		MaxAttempts: getInt("TX_RETRY_MAX_ATTEMPTS", defaultTxMaxAttempts),
+		BaseDelay:   getDuration("TX_RETRY_BASE_DELAY", defaultTxBaseDelay),
+		MaxDelay:    getDuration("TX_RETRY_MAX_DELAY", defaultTxMaxDelay),
+	}
+
+	config.HTTP = HTTPConfig{
+		Addr:            getEnvDefault("HTTP_ADDR", defaultHTTPAddr),
+		ReadTimeout:     getDuration("HTTP_READ_TIMEOUT", defaultReadTimeout),
+		WriteTimeout:    getDuration("HTTP_WRITE_TIMEOUT", defaultWriteTimeout),
+		IdleTimeout:     getDuration("HTTP_IDLE_TIMEOUT", defaultIdleTimeout),
+		ShutdownTimeout: getDuration("HTTP_SHUTDOWN_TIMEOUT", defaultShutdownTimeout),
+		RequestTimeout:  getDuration("HTTP_REQUEST_TIMEOUT", defaultRequestTimeout),
+	}
+
+	config.Metrics = MetricsConfig{
+		Addr: getEnvDefault("METRICS_ADDR", defaultMetricsAddr),
+	}
+
+	config.Holds = HoldsConfig{
+		ExpiryInterval: getDuration("HOLD_EXPIRY_INTERVAL", defaultHoldExpiryInterval),
+	}
+
+	config.FX = FXConfig{
+		RatesFile: getEnvDefault("FX_RATES_FILE", defaultFXRatesFile),
+		QuoteTTL:  getDuration("FX_QUOTE_TTL", defaultFXQuoteTTL),
+	}
+
+	config.Outbox = OutboxConfig{
+		Publisher:     getEnvDefault("OUTBOX_PUBLISHER", defaultOutboxPublisher),
+		URL:           getEnv("OUTBOX_URL"),
+		Timeout:       getDuration("OUTBOX_TIMEOUT", defaultOutboxTimeout),
+		RelayInterval: getDuration("OUTBOX_RELAY_INTERVAL", defaultOutboxRelayInterval),
+		BatchSize:     getInt("OUTBOX_BATCH_SIZE", defaultOutboxBatchSize),
+		MaxAttempts:   getInt("OUTBOX_MAX_ATTEMPTS", defaultOutboxMaxAttempts),
+		BaseDelay:     getDuration("OUTBOX_BASE_DELAY", defaultOutboxBaseDelay),
+		MaxDelay:      getDuration("OUTBOX_MAX_DELAY", defaultOutboxMaxDelay),
+	}
+
+	config.Webhooks = WebhooksConfig{
+		Timeout:          getDuration("WEBHOOK_TIMEOUT", defaultWebhookTimeout),
+		MaxAttempts:      getInt("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts),
+		BaseDelay:        getDuration("WEBHOOK_BASE_DELAY", defaultWebhookBaseDelay),
+		MaxDelay:         getDuration("WEBHOOK_MAX_DELAY", defaultWebhookMaxDelay),
+		DeliveryInterval: getDuration("WEBHOOK_DELIVERY_INTERVAL", defaultWebhookDeliveryInterval),
+		BatchSize:        getInt("WEBHOOK_BATCH_SIZE", defaultWebhookBatchSize),
+	}
+
+	config.Auth = AuthConfig{
+		BootstrapKey: getEnv("API_BOOTSTRAP_KEY"),
+	}
+
+	config.JWT = JWTConfig{
+		Issuer:      getEnv("JWT_ISSUER"),
+		Audience:    getEnv("JWT_AUDIENCE"),
+		JWKS:        getEnv("JWT_JWKS"),
+		JWKSRefresh: getDuration("JWT_JWKS_REFRESH", defaultJWKSRefresh),
+	}
+
+	config.Tracing = TracingConfig{
+		Exporter:    getEnvDefault("TRACING_EXPORTER", defaultTracingExporter),
+		ServiceName: getEnvDefault("TRACING_SERVICE_NAME", defaultTracingServiceName),
+		SampleRatio: getFloat("TRACING_SAMPLE_RATIO", defaultTracingSampleRatio),
+	}
+
 	return config
 }
 
 func getEnv(key string) string {
 	value, _ := os.LookupEnv(key)
 	return value
-}
\ No newline at end of file
+}
+
+func getEnvDefault(key, fallback string) string {
+	if value := getEnv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration %q for %s, using %s: %v", value, key, fallback, err)
+		return fallback
+	}
+	return d
+}
+
+func getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer %q for %s, using %d: %v", value, key, fallback, err)
+		return fallback
+	}
+	return n
+}
+
+func getFloat(key string, fallback float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number %q for %s, using %g: %v", value, key, fallback, err)
+		return fallback
+	}
+	return f
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// clockSkew is the leeway given to the time-based claims.
+const clockSkew = 30 * time.Second
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Verifier checks end-user JWTs: the signature against the key set, the
+// issuer, the audience and the expiry, which is required.
+type Verifier struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+func NewVerifier(keys *KeySet, issuer, audience string) *Verifier {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	)
+	return &Verifier{keys: keys, parser: parser}
+}
+
+// Verify returns the subject of a valid token.
+func (v *Verifier) Verify(ctx context.Context, token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "auth.Verify")
+	}
+	if claims.Subject == "" {
+		return "", errors.New("auth.Verify: token has no subject")
+	}
+	return claims.Subject, nil
+}
+
+type subjectKey struct{}
+
+// WithSubject marks ctx as acting for the end user subject.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext returns the end user ctx acts for, if any.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey{}).(string)
+	return subject, ok
+}
+
+type roleKey struct{}
+
+// WithRole records the role of the caller ctx acts for.
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// RoleFromContext returns the role of the caller ctx acts for, if known.
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleKey{}).(string)
+	return role, ok
+}
@@ -0,0 +1,77 @@
+// Package fx provides exchange rates and converts amounts between currencies.
+package fx
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// RateScale is the number of decimal places rates are kept with.
+const RateScale = 10
+
+// RateProvider returns how many units of to one unit of from buys.
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (*big.Rat, error)
+}
+
+// ParseRate parses a decimal rate such as "1.0850".
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, errors.Errorf("invalid rate %q", s)
+	}
+	return rate, nil
+}
+
+// FormatRate renders rate with RateScale decimal places.
+func FormatRate(rate *big.Rat) string {
+	return rate.FloatString(RateScale)
+}
+
+// RoundRate rounds rate to RateScale decimal places, so that the value used
+// for conversion is exactly the one recorded.
+func RoundRate(rate *big.Rat) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(FormatRate(rate))
+	return rounded
+}
+
+// Convert converts a positive amount of minor units of from into minor units
+// of to, rounding half up.
+func Convert(amount int64, from, to string, rate *big.Rat) (int64, error) {
+	fromExp, ok := models.CurrencyExponent(from)
+	if !ok {
+		return 0, errors.Errorf("unsupported currency %q", from)
+	}
+	toExp, ok := models.CurrencyExponent(to)
+	if !ok {
+		return 0, errors.Errorf("unsupported currency %q", to)
+	}
+
+	res := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExp-fromExp))), nil))
+	if toExp >= fromExp {
+		res.Mul(res, scale)
+	} else {
+		res.Quo(res, scale)
+	}
+
+	// Add one half and truncate.
+	num := new(big.Int).Mul(res.Num(), big.NewInt(2))
+	num.Add(num, res.Denom())
+	converted := num.Quo(num, new(big.Int).Mul(res.Denom(), big.NewInt(2)))
+	if !converted.IsInt64() {
+		return 0, errors.Wrap(models.ErrInvalidAmount, "converted amount overflows")
+	}
+	return converted.Int64(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// StaticProvider serves a fixed set of rates keyed by "FROM/TO". The inverse
+// of a configured pair is derived when only one direction is given.
+type StaticProvider struct {
+	rates map[string]*big.Rat
+}
+
+func NewStaticProvider(rates map[string]string) (*StaticProvider, error) {
+	p := &StaticProvider{rates: make(map[string]*big.Rat, len(rates))}
+	for pair, value := range rates {
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fx.NewStaticProvider %s", pair)
+		}
+		p.rates[pair] = RoundRate(rate)
+	}
+	return p, nil
+}
+
+// NewFileProvider loads rates from a JSON object such as
+// {"EUR/USD": "1.0850", "USD/JPY": "151.20"}.
+func NewFileProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "fx.NewFileProvider")
+	}
+	var rates map[string]string
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, errors.Wrapf(err, "fx.NewFileProvider %s", path)
+	}
+	return NewStaticProvider(rates)
+}
+
+func (p *StaticProvider) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	if rate, ok := p.rates[from+"/"+to]; ok {
+		return rate, nil
+	}
+	if rate, ok := p.rates[to+"/"+from]; ok {
+		return RoundRate(new(big.Rat).Inv(rate)), nil
+	}
+	return nil, errors.Wrapf(models.ErrRateUnavailable, "%s/%s", from, to)
+}
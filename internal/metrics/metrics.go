@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const namespace = "payments"
+
+// OperationTransfer labels transfers, which move money as a pair of
+// TRANSFER_OUT and TRANSFER_IN transactions.
+const OperationTransfer = "TRANSFER"
+
+// Registry holds every metric the service exposes, together with the Go
+// runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	transactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_total",
+		Help:      "Money movements by operation and outcome.",
+	}, []string{"operation", "outcome"})
+
+	transactionAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transaction_amount_total",
+		Help:      "Amounts of money movements by operation, outcome and currency, in minor units of the currency.",
+	}, []string{"operation", "outcome", "currency"})
+
+	txRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_retries_total",
+		Help:      "Database transactions re-run after a serialization failure or deadlock.",
+	}, []string{"tx"})
+
+	txRetriesExhausted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_retries_exhausted_total",
+		Help:      "Database transactions given up on after the last retry.",
+	}, []string{"tx"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		transactions,
+		transactionAmount,
+		txRetries,
+		txRetriesExhausted,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// Instrument counts and times the requests to the named route.
+func Instrument(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		method := c.Request.Method
+		httpRequests.WithLabelValues(route, method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveTransaction records a money movement of amount in currency.
+// Failures are labelled with their domain error code. The amount is only
+// recorded when the currency is known, so amounts in different currencies
+// are never added up.
+func ObserveTransaction(operation, currency string, amount int64, err error) {
+	operation = knownOperation(operation)
+	result := outcome(err)
+	transactions.WithLabelValues(operation, result).Inc()
+	if _, ok := models.CurrencyExponent(currency); ok && amount > 0 {
+		transactionAmount.WithLabelValues(operation, result, currency).Add(float64(amount))
+	}
+}
+
+// ObserveReplay records an idempotent retry answered with the original
+// transaction. No money moves, so no amount is recorded.
+func ObserveReplay(operation string) {
+	transactions.WithLabelValues(knownOperation(operation), "replayed").Inc()
+}
+
+// TxRetried records that the named database transaction is run again.
+func TxRetried(tx string) {
+	txRetries.WithLabelValues(tx).Inc()
+}
+
+// TxRetriesExhausted records that the named database transaction failed on
+// its last attempt.
+func TxRetriesExhausted(tx string) {
+	txRetriesExhausted.WithLabelValues(tx).Inc()
+}
+
+// RegisterDB exposes the connection pool statistics of db.
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// knownOperation keeps client-supplied operations from creating new label
+// values.
+func knownOperation(operation string) string {
+	switch operation {
+	case models.OperationDeposit, models.OperationWithdraw, OperationTransfer,
+		models.OperationCapture, models.OperationReversal, models.OperationRefund:
+		return operation
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func outcome(err error) string {
+	if err == nil {
+		return "success"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return models.ErrTimeout.Code
+	}
+	var domainErr *models.Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+	return "error"
+}
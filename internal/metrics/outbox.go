@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const outboxLagTimeout = 2 * time.Second
+
+var (
+	outboxPendingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "outbox", "pending_events"),
+		"Outbox events not yet published.",
+		nil, nil,
+	)
+	outboxOldestDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "outbox", "oldest_pending_age_seconds"),
+		"Age of the oldest outbox event not yet published, zero when none are pending.",
+		nil, nil,
+	)
+	outboxDeadDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "outbox", "dead_events"),
+		"Outbox events dead-lettered after running out of attempts.",
+		nil, nil,
+	)
+)
+
+// outboxCollector reads the outbox lag from the database on every scrape.
+type outboxCollector struct {
+	lag func(context.Context) (models.OutboxLag, error)
+}
+
+// RegisterOutboxLag exposes the lag reported by lag.
+func RegisterOutboxLag(lag func(context.Context) (models.OutboxLag, error)) {
+	Registry.MustRegister(&outboxCollector{lag: lag})
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboxPendingDesc
+	ch <- outboxOldestDesc
+	ch <- outboxDeadDesc
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), outboxLagTimeout)
+	defer cancel()
+
+	lag, err := c.lag(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read outbox lag")
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(outboxPendingDesc, prometheus.GaugeValue, float64(lag.Pending))
+	ch <- prometheus.MustNewConstMetric(outboxOldestDesc, prometheus.GaugeValue, lag.OldestAge.Seconds())
+	ch <- prometheus.MustNewConstMetric(outboxDeadDesc, prometheus.GaugeValue, float64(lag.Dead))
+}
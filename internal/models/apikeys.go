@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// Scopes granted to API keys. ScopeAdmin grants every other scope.
+const (
+	ScopeBalanceRead = "balance:read"
+	ScopeTransact    = "transact"
+	ScopeAdmin       = "admin"
+)
+
+var Scopes = []string{ScopeBalanceRead, ScopeTransact, ScopeAdmin}
+
+// Roles decide which operations a caller may perform once a route's scope
+// has let it in. End users with a bearer JWT are customers; API keys carry
+// one of the other roles.
+const (
+	RoleCustomer = "customer"
+	RoleSupport  = "support"
+	RoleFinance  = "finance"
+	RoleAdmin    = "admin"
+)
+
+var APIKeyRoles = []string{RoleSupport, RoleFinance, RoleAdmin}
+
+// APIKey authenticates a client. Only the SHA-256 hash of the key is stored;
+// Key holds the plaintext only in the response that creates the key.
+type APIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Scopes      []string   `json:"scopes"`
+	Role        string     `json:"role"`
+	Key         string     `json:"key,omitempty"`
+	KeyHash     string     `json:"-"`
+	RotatedFrom *string    `json:"rotated_from,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// CreateAPIKeyRequest creates a key with Role, which must be one of
+// APIKeyRoles.
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Role      string   `json:"role"`
+	ExpiresIn int64    `json:"expires_in"`
+}
+
+// RotateAPIKeyRequest replaces a key with a new one of the same name, scopes
+// and role. The old key keeps working for GracePeriod seconds.
+type RotateAPIKeyRequest struct {
+	KeyID       string `json:"-"`
+	GracePeriod int64  `json:"grace_period"`
+}
+
+// APIKeyUsage is one authenticated request, kept for auditing.
+type APIKeyUsage struct {
+	KeyID     string    `json:"-"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Status    int       `json:"status"`
+	ClientIP  string    `json:"client_ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetAPIKeyUsageRequest struct {
+	KeyID string `form:"-"`
+	Limit int    `form:"limit"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Currencies maps the supported ISO 4217 codes to the exponent of their minor
+// unit. All amounts are stored and exchanged as int64 minor units.
+var Currencies = map[string]int{
+	"AUD": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"CZK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"PLN": 2,
+	"RUB": 2,
+	"SEK": 2,
+	"USD": 2,
+}
+
+// CurrencyExponent reports the minor unit exponent of code.
+func CurrencyExponent(code string) (int, bool) {
+	exponent, ok := Currencies[code]
+	return exponent, ok
+}
+
+// FormatAmount renders amount minor units as a decimal string, e.g. 12345 USD
+// as "123.45".
+func FormatAmount(amount int64, currency string) string {
+	exponent := Currencies[currency]
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absMinorUnits(amount), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func absMinorUnits(amount int64) uint64 {
+	if amount < 0 {
+		return uint64(-(amount + 1)) + 1
+	}
+	return uint64(amount)
+}
@@ -0,0 +1,104 @@
+package models
+
+import "fmt"
+
+// Error is a domain error with a stable machine-readable code. Repository and
+// usecase wrap these sentinels with context, transport maps them to HTTP
+// responses.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrInvalidRequest      = &Error{Code: "invalid_request", Message: "invalid request"}
+	ErrUnauthorized        = &Error{Code: "unauthorized", Message: "missing or invalid credentials"}
+	ErrForbidden           = &Error{Code: "forbidden", Message: "credentials do not allow this request"}
+	ErrAPIKeyNotFound      = &Error{Code: "api_key_not_found", Message: "API key not found"}
+	ErrAPIKeyRevoked       = &Error{Code: "api_key_revoked", Message: "API key is revoked or expired"}
+	ErrInvalidAmount       = &Error{Code: "invalid_amount", Message: "amount must be > 0"}
+	ErrUnknownOperation    = &Error{Code: "unknown_operation", Message: "unknown operation"}
+	ErrWalletNotFound      = &Error{Code: "wallet_not_found", Message: "wallet not found"}
+	ErrOwnerNotFound       = &Error{Code: "wallet_owner_not_found", Message: "wallet owner not found"}
+	ErrWalletFrozen        = &Error{Code: "wallet_frozen", Message: "wallet is frozen"}
+	ErrWalletClosed        = &Error{Code: "wallet_closed", Message: "wallet is closed"}
+	ErrWalletNotEmpty      = &Error{Code: "wallet_not_empty", Message: "wallet balance must be zero to close it"}
+	ErrStatusTransition    = &Error{Code: "invalid_status_transition", Message: "wallet status change is not allowed"}
+	ErrInsufficientFunds   = &Error{Code: "insufficient_funds", Message: "insufficient funds"}
+	ErrLimitExceeded       = &Error{Code: "limit_exceeded", Message: "transaction limit exceeded"}
+	ErrCreditLimitExceeded = &Error{Code: "credit_limit_exceeded", Message: "credit line is exhausted"}
+	ErrCurrencyMismatch    = &Error{Code: "currency_mismatch", Message: "currency does not match the wallet currency"}
+	ErrRateUnavailable     = &Error{Code: "rate_unavailable", Message: "exchange rate is not available"}
+	ErrQuoteNotFound       = &Error{Code: "quote_not_found", Message: "quote not found"}
+	ErrQuoteExpired        = &Error{Code: "quote_expired", Message: "quote has expired or was already used"}
+	ErrTransactionNotFound = &Error{Code: "transaction_not_found", Message: "transaction not found"}
+	ErrNotReversible       = &Error{Code: "not_reversible", Message: "transaction cannot be reversed"}
+	ErrReversalExceeded    = &Error{Code: "reversal_exceeded", Message: "amount exceeds the unreversed part of the transaction"}
+	ErrHoldNotFound        = &Error{Code: "hold_not_found", Message: "hold not found"}
+	ErrHoldNotActive       = &Error{Code: "hold_not_active", Message: "hold is no longer active"}
+	ErrWebhookNotFound     = &Error{Code: "webhook_not_found", Message: "webhook endpoint not found"}
+	ErrDeliveryNotFound    = &Error{Code: "webhook_delivery_not_found", Message: "webhook delivery not found"}
+	ErrConflict            = &Error{Code: "conflict", Message: "conflict"}
+	ErrWalletExists        = &Error{Code: "wallet_exists", Message: "wallet already exists"}
+	ErrIdempotencyConflict = &Error{Code: "idempotency_conflict", Message: "idempotency key was already used with a different request"}
+	ErrTimeout             = &Error{Code: "timeout", Message: "request timed out"}
+	ErrInternal            = &Error{Code: "internal", Message: "internal error"}
+)
+
+// InsufficientFundsError is returned when a debit exceeds the available
+// balance of a wallet without a credit line. It matches ErrInsufficientFunds with errors.Is.
+type InsufficientFundsError struct {
+	Available int64
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("%v: available balance %d", ErrInsufficientFunds, e.Available)
+}
+
+func (e *InsufficientFundsError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// CreditLimitExceededError is returned instead of InsufficientFundsError when
+// a debit would take a wallet with a credit line past its credit limit. It
+// matches ErrCreditLimitExceeded with errors.Is.
+type CreditLimitExceededError struct {
+	Available   int64
+	CreditLimit int64
+}
+
+func (e *CreditLimitExceededError) Error() string {
+	return fmt.Sprintf("%v: available balance %d, credit limit %d", ErrCreditLimitExceeded, e.Available, e.CreditLimit)
+}
+
+func (e *CreditLimitExceededError) Unwrap() error {
+	return ErrCreditLimitExceeded
+}
+
+// Limit rules reported by LimitExceededError.
+const (
+	RuleMaxSingleAmount      = "max_single_amount"
+	RuleDailyWithdrawalCap   = "daily_withdrawal_cap"
+	RuleMonthlyWithdrawalCap = "monthly_withdrawal_cap"
+	RuleMaxOperations        = "max_operations"
+	RuleMinBalance           = "min_balance"
+)
+
+// LimitExceededError names the limit rule a transaction violates. It matches
+// ErrLimitExceeded with errors.Is.
+type LimitExceededError struct {
+	Rule  string
+	Limit int64
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%v: %s %d", ErrLimitExceeded, e.Rule, e.Limit)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
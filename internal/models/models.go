@@ -1,11 +1,394 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OperationDeposit  = "DEPOSIT"
+	OperationWithdraw = "WITHDRAW"
+
+	OperationTransferOut = "TRANSFER_OUT"
+	OperationTransferIn  = "TRANSFER_IN"
+
+	OperationCapture = "CAPTURE"
+
+	// OperationRefund returns money taken by a withdrawal or capture,
+	// OperationReversal takes back a deposit.
+	OperationRefund   = "REFUND"
+	OperationReversal = "REVERSAL"
+)
+
 type WalletTransaction struct {
-	WalletID  string `json:"wallet_id"`
-	Operation string `json:"operation"`
-	Amount    int64  `json:"amount"`
+	WalletID       string `json:"wallet_id"`
+	Operation      string `json:"operation"`
+	Amount         int64  `json:"amount"`
+	Currency       string `json:"currency,omitempty"`
+	IdempotencyKey string `json:"-"`
+}
+
+// Idempotency identifies a client request that must be applied at most once
+// to a wallet.
+// RequestHash fingerprints the request body so that reusing a key with a
+// different body can be detected.
+type Idempotency struct {
+	Key         string
+	RequestHash string
+}
+
+type Transaction struct {
+	ID                    string    `json:"transaction_id"`
+	WalletID              string    `json:"wallet_id"`
+	Operation             string    `json:"operation"`
+	Amount                int64     `json:"amount"`
+	Currency              string    `json:"currency"`
+	Balance               int64     `json:"balance"`
+	TransferID            *string   `json:"transfer_id,omitempty"`
+	HoldID                *string   `json:"hold_id,omitempty"`
+	Status                string    `json:"status"`
+	ReversedAmount        int64     `json:"reversed_amount"`
+	OriginalTransactionID *string   `json:"original_transaction_id,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+	Replayed              bool      `json:"-"`
+}
+
+const (
+	TransactionStatusCompleted         = "COMPLETED"
+	TransactionStatusPartiallyReversed = "PARTIALLY_REVERSED"
+	TransactionStatusReversed          = "REVERSED"
+)
+
+// ReverseTransactionRequest undoes a transaction fully, or partially when
+// Amount is set.
+type ReverseTransactionRequest struct {
+	TransactionID string `json:"-"`
+	Amount        *int64 `json:"amount"`
+}
+
+// Reversal pairs the updated original transaction with the compensating one.
+type Reversal struct {
+	Original Transaction `json:"original"`
+	Reversal Transaction `json:"reversal"`
+}
+
+type TransferRequest struct {
+	FromWalletID string `json:"from_wallet_id"`
+	ToWalletID   string `json:"to_wallet_id"`
+	Amount       int64  `json:"amount"`
+	QuoteID      string `json:"quote_id,omitempty"`
+}
+
+// Conversion describes the currency exchange of a cross-currency transfer.
+// Amount is the credited amount in minor units of ToCurrency.
+type Conversion struct {
+	FromCurrency string
+	ToCurrency   string
+	Rate         string
+	Amount       int64
+	QuoteID      uuid.NullUUID
+}
+
+type Transfer struct {
+	ID           string      `json:"transfer_id"`
+	FromWalletID string      `json:"from_wallet_id"`
+	ToWalletID   string      `json:"to_wallet_id"`
+	Amount       int64       `json:"amount"`
+	FromCurrency string      `json:"from_currency"`
+	ToCurrency   string      `json:"to_currency"`
+	ToAmount     int64       `json:"to_amount"`
+	Rate         *string     `json:"rate,omitempty"`
+	QuoteID      *string     `json:"quote_id,omitempty"`
+	Debit        Transaction `json:"debit"`
+	Credit       Transaction `json:"credit"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type CreateWalletRequest struct {
+	ID       string          `json:"id"`
+	OwnerRef string          `json:"owner_ref"`
+	Tier     string          `json:"tier"`
+	Currency string          `json:"currency"`
+	Metadata json.RawMessage `json:"metadata"`
 }
 
+type Wallet struct {
+	ID              string          `json:"id"`
+	OwnerRef        *string         `json:"owner_ref,omitempty"`
+	Currency        string          `json:"currency"`
+	Balance         int64           `json:"balance"`
+	Status          string          `json:"status"`
+	DepositsBlocked bool            `json:"deposits_blocked"`
+	Tier            string          `json:"tier"`
+	CreditLimit     int64           `json:"credit_limit"`
+	Limits          Limits          `json:"-"`
+	Metadata        json.RawMessage `json:"metadata"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// Frozen wallets reject debits, and credits too when DepositsBlocked is set.
+// Closed wallets reject everything.
+const (
+	WalletStatusActive = "ACTIVE"
+	WalletStatusFrozen = "FROZEN"
+	WalletStatusClosed = "CLOSED"
+)
+
+type WalletStatusRequest struct {
+	WalletID      string `json:"-"`
+	Reason        string `json:"reason"`
+	BlockDeposits bool   `json:"block_deposits"`
+}
+
+type WalletStatusChange struct {
+	WalletID        string    `json:"wallet_id"`
+	FromStatus      string    `json:"from_status"`
+	ToStatus        string    `json:"to_status"`
+	DepositsBlocked bool      `json:"deposits_blocked"`
+	Reason          string    `json:"reason"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// GetBalanceResponse carries balances in minor units of Currency together
+// with their decimal representation.
 type GetBalanceResponse struct {
-	Amount float64 `json:"balance"`
+	Currency           string `json:"currency"`
+	Ledger             int64  `json:"ledger"`
+	Available          int64  `json:"available"`
+	CreditLimit        int64  `json:"credit_limit"`
+	CreditUsed         int64  `json:"credit_used"`
+	LedgerFormatted    string `json:"ledger_formatted"`
+	AvailableFormatted string `json:"available_formatted"`
+}
+
+const (
+	HoldStatusActive   = "ACTIVE"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusVoided   = "VOIDED"
+	HoldStatusExpired  = "EXPIRED"
+)
+
+type CreateHoldRequest struct {
+	WalletID  string `json:"-"`
+	Amount    int64  `json:"amount"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+type CaptureHoldRequest struct {
+	HoldID string `json:"-"`
+	Amount *int64 `json:"amount"`
+}
+
+type Hold struct {
+	ID             string    `json:"hold_id"`
+	WalletID       string    `json:"wallet_id"`
+	Amount         int64     `json:"amount"`
+	CapturedAmount int64     `json:"captured_amount"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CaptureHoldResponse struct {
+	Hold        Hold        `json:"hold"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type GetTransactionsRequest struct {
+	WalletID  string `form:"-"`
+	Operation string `form:"operation"`
+	MinAmount *int64 `form:"min_amount"`
+	MaxAmount *int64 `form:"max_amount"`
+	From      string `form:"from"`
+	To        string `form:"to"`
+	Sort      string `form:"sort"`
+	Cursor    string `form:"cursor"`
+	Limit     int    `form:"limit"`
+}
+
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type TransactionFilter struct {
+	WalletID   uuid.UUID
+	Operation  string
+	MinAmount  *int64
+	MaxAmount  *int64
+	From       *time.Time
+	To         *time.Time
+	Descending bool
+	After      *TransactionCursor
+	Limit      int
+}
+
+type TransactionList struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+type Reconciliation struct {
+	WalletID      string `json:"wallet_id"`
+	Balance       int64  `json:"balance"`
+	LedgerBalance int64  `json:"ledger_balance"`
+	Difference    int64  `json:"difference"`
+	Balanced      bool   `json:"balanced"`
+}
+
+type TrialBalanceLine struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+}
+
+type TrialBalance struct {
+	Lines    []TrialBalanceLine `json:"lines"`
+	Balanced bool               `json:"balanced"`
+}
+
+type CreateQuoteRequest struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	Amount       int64  `json:"amount"`
+}
+
+// Quote locks an exchange rate for a single transfer until ExpiresAt.
+type Quote struct {
+	ID              string     `json:"quote_id"`
+	FromCurrency    string     `json:"from_currency"`
+	ToCurrency      string     `json:"to_currency"`
+	Rate            string     `json:"rate"`
+	Amount          int64      `json:"amount"`
+	ConvertedAmount int64      `json:"converted_amount"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	UsedAt          *time.Time `json:"used_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Limits are the transaction limits of a wallet or a tier. A nil field means
+// the limit is not set.
+type Limits struct {
+	MaxSingleAmount         *int64 `json:"max_single_amount"`
+	DailyWithdrawalCap      *int64 `json:"daily_withdrawal_cap"`
+	MonthlyWithdrawalCap    *int64 `json:"monthly_withdrawal_cap"`
+	MaxOperations           *int64 `json:"max_operations"`
+	OperationsWindowSeconds *int64 `json:"operations_window_seconds"`
+	MinBalance              *int64 `json:"min_balance"`
+}
+
+// LimitUsage is what a wallet has already used of its limits.
+type LimitUsage struct {
+	Available          int64
+	WithdrawnToday     int64
+	WithdrawnThisMonth int64
+	Operations         int64
+}
+
+// LimitWindows are the starts of the periods LimitUsage is counted over.
+type LimitWindows struct {
+	Day        time.Time
+	Month      time.Time
+	Operations time.Time
+}
+
+// Windows returns the periods usage is counted over for a transaction made
+// at now.
+func (l Limits) Windows(now time.Time) LimitWindows {
+	now = now.UTC()
+	windows := LimitWindows{
+		Day:        time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Month:      time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		Operations: now,
+	}
+	if l.OperationsWindowSeconds != nil {
+		windows.Operations = now.Add(-time.Duration(*l.OperationsWindowSeconds) * time.Second)
+	}
+	return windows
+}
+
+// NeedsUsage reports whether checking a transaction depends on what the
+// wallet has already used.
+func (l Limits) NeedsUsage(debit bool) bool {
+	return l.MaxOperations != nil ||
+		debit && (l.DailyWithdrawalCap != nil || l.MonthlyWithdrawalCap != nil || l.MinBalance != nil)
+}
+
+// Check evaluates a new transaction of amount against the limits, given the
+// usage of the wallet so far.
+func (l Limits) Check(usage LimitUsage, amount int64, debit bool) error {
+	if l.MaxSingleAmount != nil && amount > *l.MaxSingleAmount {
+		return &LimitExceededError{Rule: RuleMaxSingleAmount, Limit: *l.MaxSingleAmount}
+	}
+	if l.MaxOperations != nil && usage.Operations >= *l.MaxOperations {
+		return &LimitExceededError{Rule: RuleMaxOperations, Limit: *l.MaxOperations}
+	}
+	if !debit {
+		return nil
+	}
+	if l.DailyWithdrawalCap != nil && usage.WithdrawnToday+amount > *l.DailyWithdrawalCap {
+		return &LimitExceededError{Rule: RuleDailyWithdrawalCap, Limit: *l.DailyWithdrawalCap}
+	}
+	if l.MonthlyWithdrawalCap != nil && usage.WithdrawnThisMonth+amount > *l.MonthlyWithdrawalCap {
+		return &LimitExceededError{Rule: RuleMonthlyWithdrawalCap, Limit: *l.MonthlyWithdrawalCap}
+	}
+	if l.MinBalance != nil && usage.Available-amount < *l.MinBalance {
+		return &LimitExceededError{Rule: RuleMinBalance, Limit: *l.MinBalance}
+	}
+	return nil
+}
+
+type SetLimitsRequest struct {
+	WalletID string `json:"-"`
+	Tier     string `json:"-"`
+	Limits
+}
+
+type SetCreditLimitRequest struct {
+	WalletID    string `json:"-"`
+	CreditLimit *int64 `json:"credit_limit"`
+}
+
+type SetTierRequest struct {
+	WalletID string `json:"-"`
+	Tier     string `json:"tier"`
+}
+
+const EventBalanceChanged = "wallet.balance_changed"
+
+// OutboxEvent is an event recorded in the same transaction as the change it
+// describes and relayed to downstream consumers afterwards.
+type OutboxEvent struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"-"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// OutboxLag describes the events waiting to be relayed and those that were
+// dead-lettered after running out of attempts.
+type OutboxLag struct {
+	Pending   int64
+	OldestAge time.Duration
+	Dead      int64
+}
+
+// BalanceChangedEvent is the payload of EventBalanceChanged.
+type BalanceChangedEvent struct {
+	TransactionID         string    `json:"transaction_id"`
+	WalletID              string    `json:"wallet_id"`
+	Operation             string    `json:"operation"`
+	Amount                int64     `json:"amount"`
+	Currency              string    `json:"currency"`
+	BalanceAfter          int64     `json:"balance_after"`
+	TransferID            *string   `json:"transfer_id,omitempty"`
+	HoldID                *string   `json:"hold_id,omitempty"`
+	OriginalTransactionID *string   `json:"original_transaction_id,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// WalletOwner links a wallet to an end user, identified by the sub claim of
+// their JWT. A wallet may have several owners.
+type WalletOwner struct {
+	WalletID  string    `json:"wallet_id"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WalletOwnerRequest struct {
+	WalletID string `json:"-"`
+	Subject  string `json:"-"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookEventDeposit      = "wallet.deposit"
+	WebhookEventWithdraw     = "wallet.withdraw"
+	WebhookEventTransfer     = "wallet.transfer"
+	WebhookEventReversal     = "wallet.reversal"
+	WebhookEventHoldCaptured = "hold.captured"
+)
+
+// WebhookEventTypes maps each operation to the webhook event it triggers.
+var WebhookEventTypes = map[string]string{
+	OperationDeposit:     WebhookEventDeposit,
+	OperationWithdraw:    WebhookEventWithdraw,
+	OperationTransferOut: WebhookEventTransfer,
+	OperationTransferIn:  WebhookEventTransfer,
+	OperationCapture:     WebhookEventHoldCaptured,
+	OperationRefund:      WebhookEventReversal,
+	OperationReversal:    WebhookEventReversal,
+}
+
+// Pending deliveries are retried until they are delivered or run out of
+// attempts and become dead. Dead deliveries stay until they are replayed.
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryDead      = "DEAD"
+)
+
+// WebhookEndpoint receives the subscribed events of one wallet, or of all
+// wallets when WalletID is nil. Secret is only returned on creation.
+type WebhookEndpoint struct {
+	ID         string    `json:"id"`
+	WalletID   *string   `json:"wallet_id,omitempty"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type CreateWebhookRequest struct {
+	WalletID   string   `json:"wallet_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+// WebhookDelivery is one event queued for one endpoint.
+type WebhookDelivery struct {
+	ID            string           `json:"id"`
+	EndpointID    string           `json:"endpoint_id"`
+	EventID       int64            `json:"event_id"`
+	EventType     string           `json:"event_type"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt time.Time        `json:"next_attempt_at"`
+	LastError     *string          `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time       `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	History       []WebhookAttempt `json:"history,omitempty"`
+
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+type WebhookAttempt struct {
+	DeliveryID  string    `json:"-"`
+	Succeeded   bool      `json:"succeeded"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       *string   `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type GetWebhookDeliveriesRequest struct {
+	EndpointID string `form:"endpoint_id"`
+	Status     string `form:"status"`
+	Limit      int    `form:"limit"`
+}
+
+type WebhookDeliveryFilter struct {
+	EndpointID uuid.NullUUID
+	Status     string
+	Limit      int
+}
+
+// WebhookEvent is the body POSTed to webhook endpoints. ID is shared by all
+// deliveries of the same event, so receivers can deduplicate on it.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const (
+	PublisherLog  = "log"
+	PublisherHTTP = "http"
+)
+
+// Publisher delivers outbox events to downstream consumers. Delivery is
+// at-least-once: an event may be published again if marking it as published
+// fails, so consumers should deduplicate on the event ID. A failed event is
+// retried later without holding up the events after it, so consumers must
+// not rely on the order of events.
+type Publisher interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+// NewPublisher returns the publisher named by kind.
+func NewPublisher(kind, url string, timeout time.Duration) (Publisher, error) {
+	switch kind {
+	case PublisherLog:
+		return LogPublisher{}, nil
+	case PublisherHTTP:
+		if url == "" {
+			return nil, errors.New("outbox.NewPublisher: http publisher requires a URL")
+		}
+		return NewHTTPPublisher(url, timeout), nil
+	default:
+		return nil, errors.Errorf("outbox.NewPublisher: unknown publisher %q", kind)
+	}
+}
+
+// LogPublisher writes events to the application log.
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(_ context.Context, event models.OutboxEvent) error {
+	logrus.WithFields(logrus.Fields{
+		"event_id":     event.ID,
+		"event_type":   event.Type,
+		"aggregate_id": event.AggregateID,
+	}).Infof("Outbox event: %s", event.Payload)
+	return nil
+}
+
+// HTTPPublisher POSTs each event as JSON to a fixed URL. Any non-2xx
+// response counts as a failed delivery.
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "outbox.HTTPPublisher")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "outbox.HTTPPublisher")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "outbox.HTTPPublisher")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Wrap(fmt.Errorf("unexpected status %d", resp.StatusCode), "outbox.HTTPPublisher")
+	}
+	return nil
+}
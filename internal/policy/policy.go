@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/auth"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/usecase"
+)
+
+// Anyone marks methods that need no role: those run by background workers
+// and by authentication itself.
+const Anyone = "*"
+
+// Rules maps each UseCase method to the roles allowed to call it. Admins may
+// call every method; methods missing from the rules may be called by no one
+// else.
+type Rules map[string][]string
+
+var (
+	customer = models.RoleCustomer
+	support  = models.RoleSupport
+	finance  = models.RoleFinance
+	admin    = models.RoleAdmin
+)
+
+// DefaultRules lets customers move money between and read their own wallets,
+// support agents look after wallets and their owners, and finance move money,
+// place and capture holds, manage limits, credit and reversals, and register
+// and list webhooks for their integrations. Closing wallets, reading and
+// deleting single webhooks and API keys are left to admins. Customers are
+// limited to the wallets they own by the usecase itself.
+var DefaultRules = Rules{
+	"WalletTransaction": {customer, finance},
+	"Transfer":          {customer, finance},
+	"GetBalance":        {customer, support, finance},
+	"GetTransactions":   {customer, support, finance},
+	"GetWalletLimits":   {customer, support, finance},
+
+	"CreateWallet":           {support},
+	"FreezeWallet":           {support},
+	"UnfreezeWallet":         {support},
+	"GetWalletStatusHistory": {support, finance},
+	"GetWalletOwners":        {support},
+	"AddWalletOwner":         {support},
+	"RemoveWalletOwner":      {support},
+	"GetHold":                {support, finance},
+	"VoidHold":               {support, finance},
+	"GetQuote":               {support, finance},
+	"GetWebhookDeliveries":   {support},
+	"GetWebhookDelivery":     {support},
+	"ReplayWebhookDelivery":  {support},
+
+	"ReverseTransaction": {finance},
+	"ReconcileWallet":    {finance},
+	"GetTrialBalance":    {finance},
+	"SetWalletLimits":    {finance},
+	"SetTierLimits":      {finance},
+	"SetWalletTier":      {finance},
+	"SetCreditLimit":     {finance},
+	"CreateQuote":        {finance},
+	"CreateHold":         {finance},
+	"CaptureHold":        {finance},
+	"CreateWebhook":      {finance},
+	"GetWebhooks":        {finance},
+
+	"CloseWallet":    {admin},
+	"GetWebhook":     {admin},
+	"DeleteWebhook":  {admin},
+	"CreateAPIKey":   {admin},
+	"GetAPIKeys":     {admin},
+	"RotateAPIKey":   {admin},
+	"RevokeAPIKey":   {admin},
+	"GetAPIKeyUsage": {admin},
+
+	"ExpireHolds":        {Anyone},
+	"RelayOutbox":        {Anyone},
+	"GetOutboxLag":       {Anyone},
+	"DeliverWebhooks":    {Anyone},
+	"AuthenticateAPIKey": {Anyone},
+	"RecordAPIKeyUsage":  {Anyone},
+	"BootstrapAPIKey":    {Anyone},
+}
+
+// Usecase enforces rules on the role in the context of each call before
+// passing it on to the wrapped UseCase.
+type Usecase struct {
+	next  usecase.UseCase
+	rules Rules
+}
+
+func NewUsecase(next usecase.UseCase, rules Rules) usecase.UseCase {
+	return &Usecase{next: next, rules: rules}
+}
+
+// Allowed reports whether role may call method under the rules.
+func (r Rules) Allowed(role, method string) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	for _, allowed := range r[method] {
+		if allowed == Anyone || allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Usecase) authorize(ctx context.Context, method string) error {
+	role, _ := auth.RoleFromContext(ctx)
+	if !p.rules.Allowed(role, method) {
+		return errors.Wrapf(models.ErrForbidden, "policy: role %q may not call %s", role, method)
+	}
+	return nil
+}
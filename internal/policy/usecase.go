@@ -0,0 +1,324 @@
+package policy
+
+import (
+	"context"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/outbox"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
+)
+
+func (p *Usecase) WalletTransaction(ctx context.Context, data models.WalletTransaction) (models.Transaction, error) {
+	if err := p.authorize(ctx, "WalletTransaction"); err != nil {
+		return models.Transaction{}, err
+	}
+	return p.next.WalletTransaction(ctx, data)
+}
+
+func (p *Usecase) Transfer(ctx context.Context, data models.TransferRequest) (models.Transfer, error) {
+	if err := p.authorize(ctx, "Transfer"); err != nil {
+		return models.Transfer{}, err
+	}
+	return p.next.Transfer(ctx, data)
+}
+
+func (p *Usecase) GetBalance(ctx context.Context, id string) (models.GetBalanceResponse, error) {
+	if err := p.authorize(ctx, "GetBalance"); err != nil {
+		return models.GetBalanceResponse{}, err
+	}
+	return p.next.GetBalance(ctx, id)
+}
+
+func (p *Usecase) GetTransactions(ctx context.Context, data models.GetTransactionsRequest) (models.TransactionList, error) {
+	if err := p.authorize(ctx, "GetTransactions"); err != nil {
+		return models.TransactionList{}, err
+	}
+	return p.next.GetTransactions(ctx, data)
+}
+
+func (p *Usecase) CreateWallet(ctx context.Context, data models.CreateWalletRequest) (models.Wallet, error) {
+	if err := p.authorize(ctx, "CreateWallet"); err != nil {
+		return models.Wallet{}, err
+	}
+	return p.next.CreateWallet(ctx, data)
+}
+
+func (p *Usecase) ReconcileWallet(ctx context.Context, id string) (models.Reconciliation, error) {
+	if err := p.authorize(ctx, "ReconcileWallet"); err != nil {
+		return models.Reconciliation{}, err
+	}
+	return p.next.ReconcileWallet(ctx, id)
+}
+
+func (p *Usecase) GetTrialBalance(ctx context.Context) (models.TrialBalance, error) {
+	if err := p.authorize(ctx, "GetTrialBalance"); err != nil {
+		return models.TrialBalance{}, err
+	}
+	return p.next.GetTrialBalance(ctx)
+}
+
+func (p *Usecase) CreateQuote(ctx context.Context, data models.CreateQuoteRequest) (models.Quote, error) {
+	if err := p.authorize(ctx, "CreateQuote"); err != nil {
+		return models.Quote{}, err
+	}
+	return p.next.CreateQuote(ctx, data)
+}
+
+func (p *Usecase) GetQuote(ctx context.Context, id string) (models.Quote, error) {
+	if err := p.authorize(ctx, "GetQuote"); err != nil {
+		return models.Quote{}, err
+	}
+	return p.next.GetQuote(ctx, id)
+}
+
+func (p *Usecase) ReverseTransaction(ctx context.Context, data models.ReverseTransactionRequest) (models.Reversal, error) {
+	if err := p.authorize(ctx, "ReverseTransaction"); err != nil {
+		return models.Reversal{}, err
+	}
+	return p.next.ReverseTransaction(ctx, data)
+}
+
+func (p *Usecase) FreezeWallet(ctx context.Context, data models.WalletStatusRequest) (models.Wallet, error) {
+	if err := p.authorize(ctx, "FreezeWallet"); err != nil {
+		return models.Wallet{}, err
+	}
+	return p.next.FreezeWallet(ctx, data)
+}
+
+func (p *Usecase) UnfreezeWallet(ctx context.Context, data models.WalletStatusRequest) (models.Wallet, error) {
+	if err := p.authorize(ctx, "UnfreezeWallet"); err != nil {
+		return models.Wallet{}, err
+	}
+	return p.next.UnfreezeWallet(ctx, data)
+}
+
+func (p *Usecase) CloseWallet(ctx context.Context, data models.WalletStatusRequest) (models.Wallet, error) {
+	if err := p.authorize(ctx, "CloseWallet"); err != nil {
+		return models.Wallet{}, err
+	}
+	return p.next.CloseWallet(ctx, data)
+}
+
+func (p *Usecase) GetWalletStatusHistory(ctx context.Context, id string) ([]models.WalletStatusChange, error) {
+	if err := p.authorize(ctx, "GetWalletStatusHistory"); err != nil {
+		return nil, err
+	}
+	return p.next.GetWalletStatusHistory(ctx, id)
+}
+
+func (p *Usecase) GetWalletLimits(ctx context.Context, id string) (models.Limits, error) {
+	if err := p.authorize(ctx, "GetWalletLimits"); err != nil {
+		return models.Limits{}, err
+	}
+	return p.next.GetWalletLimits(ctx, id)
+}
+
+func (p *Usecase) SetWalletLimits(ctx context.Context, data models.SetLimitsRequest) (models.Limits, error) {
+	if err := p.authorize(ctx, "SetWalletLimits"); err != nil {
+		return models.Limits{}, err
+	}
+	return p.next.SetWalletLimits(ctx, data)
+}
+
+func (p *Usecase) SetTierLimits(ctx context.Context, data models.SetLimitsRequest) (models.Limits, error) {
+	if err := p.authorize(ctx, "SetTierLimits"); err != nil {
+		return models.Limits{}, err
+	}
+	return p.next.SetTierLimits(ctx, data)
+}
+
+func (p *Usecase) SetWalletTier(ctx context.Context, data models.SetTierRequest) (models.Wallet, error) {
+	if err := p.authorize(ctx, "SetWalletTier"); err != nil {
+		return models.Wallet{}, err
+	}
+	return p.next.SetWalletTier(ctx, data)
+}
+
+func (p *Usecase) SetCreditLimit(ctx context.Context, data models.SetCreditLimitRequest) (models.Wallet, error) {
+	if err := p.authorize(ctx, "SetCreditLimit"); err != nil {
+		return models.Wallet{}, err
+	}
+	return p.next.SetCreditLimit(ctx, data)
+}
+
+func (p *Usecase) GetWalletOwners(ctx context.Context, id string) ([]models.WalletOwner, error) {
+	if err := p.authorize(ctx, "GetWalletOwners"); err != nil {
+		return nil, err
+	}
+	return p.next.GetWalletOwners(ctx, id)
+}
+
+func (p *Usecase) AddWalletOwner(ctx context.Context, data models.WalletOwnerRequest) (models.WalletOwner, error) {
+	if err := p.authorize(ctx, "AddWalletOwner"); err != nil {
+		return models.WalletOwner{}, err
+	}
+	return p.next.AddWalletOwner(ctx, data)
+}
+
+func (p *Usecase) RemoveWalletOwner(ctx context.Context, data models.WalletOwnerRequest) error {
+	if err := p.authorize(ctx, "RemoveWalletOwner"); err != nil {
+		return err
+	}
+	return p.next.RemoveWalletOwner(ctx, data)
+}
+
+func (p *Usecase) CreateHold(ctx context.Context, data models.CreateHoldRequest) (models.Hold, error) {
+	if err := p.authorize(ctx, "CreateHold"); err != nil {
+		return models.Hold{}, err
+	}
+	return p.next.CreateHold(ctx, data)
+}
+
+func (p *Usecase) GetHold(ctx context.Context, id string) (models.Hold, error) {
+	if err := p.authorize(ctx, "GetHold"); err != nil {
+		return models.Hold{}, err
+	}
+	return p.next.GetHold(ctx, id)
+}
+
+func (p *Usecase) CaptureHold(ctx context.Context, data models.CaptureHoldRequest) (models.CaptureHoldResponse, error) {
+	if err := p.authorize(ctx, "CaptureHold"); err != nil {
+		return models.CaptureHoldResponse{}, err
+	}
+	return p.next.CaptureHold(ctx, data)
+}
+
+func (p *Usecase) VoidHold(ctx context.Context, id string) (models.Hold, error) {
+	if err := p.authorize(ctx, "VoidHold"); err != nil {
+		return models.Hold{}, err
+	}
+	return p.next.VoidHold(ctx, id)
+}
+
+func (p *Usecase) ExpireHolds(ctx context.Context) (int64, error) {
+	if err := p.authorize(ctx, "ExpireHolds"); err != nil {
+		return 0, err
+	}
+	return p.next.ExpireHolds(ctx)
+}
+
+func (p *Usecase) RelayOutbox(ctx context.Context, publisher outbox.Publisher, policy webhook.RetryPolicy, limit int) (int, error) {
+	if err := p.authorize(ctx, "RelayOutbox"); err != nil {
+		return 0, err
+	}
+	return p.next.RelayOutbox(ctx, publisher, policy, limit)
+}
+
+func (p *Usecase) GetOutboxLag(ctx context.Context) (models.OutboxLag, error) {
+	if err := p.authorize(ctx, "GetOutboxLag"); err != nil {
+		return models.OutboxLag{}, err
+	}
+	return p.next.GetOutboxLag(ctx)
+}
+
+func (p *Usecase) CreateWebhook(ctx context.Context, data models.CreateWebhookRequest) (models.WebhookEndpoint, error) {
+	if err := p.authorize(ctx, "CreateWebhook"); err != nil {
+		return models.WebhookEndpoint{}, err
+	}
+	return p.next.CreateWebhook(ctx, data)
+}
+
+func (p *Usecase) GetWebhooks(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	if err := p.authorize(ctx, "GetWebhooks"); err != nil {
+		return nil, err
+	}
+	return p.next.GetWebhooks(ctx)
+}
+
+func (p *Usecase) GetWebhook(ctx context.Context, id string) (models.WebhookEndpoint, error) {
+	if err := p.authorize(ctx, "GetWebhook"); err != nil {
+		return models.WebhookEndpoint{}, err
+	}
+	return p.next.GetWebhook(ctx, id)
+}
+
+func (p *Usecase) DeleteWebhook(ctx context.Context, id string) (models.WebhookEndpoint, error) {
+	if err := p.authorize(ctx, "DeleteWebhook"); err != nil {
+		return models.WebhookEndpoint{}, err
+	}
+	return p.next.DeleteWebhook(ctx, id)
+}
+
+func (p *Usecase) GetWebhookDeliveries(ctx context.Context, data models.GetWebhookDeliveriesRequest) ([]models.WebhookDelivery, error) {
+	if err := p.authorize(ctx, "GetWebhookDeliveries"); err != nil {
+		return nil, err
+	}
+	return p.next.GetWebhookDeliveries(ctx, data)
+}
+
+func (p *Usecase) GetWebhookDelivery(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	if err := p.authorize(ctx, "GetWebhookDelivery"); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return p.next.GetWebhookDelivery(ctx, id)
+}
+
+func (p *Usecase) ReplayWebhookDelivery(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	if err := p.authorize(ctx, "ReplayWebhookDelivery"); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return p.next.ReplayWebhookDelivery(ctx, id)
+}
+
+func (p *Usecase) DeliverWebhooks(ctx context.Context, sender webhook.Sender, policy webhook.RetryPolicy, limit int) (int, error) {
+	if err := p.authorize(ctx, "DeliverWebhooks"); err != nil {
+		return 0, err
+	}
+	return p.next.DeliverWebhooks(ctx, sender, policy, limit)
+}
+
+func (p *Usecase) CreateAPIKey(ctx context.Context, data models.CreateAPIKeyRequest) (models.APIKey, error) {
+	if err := p.authorize(ctx, "CreateAPIKey"); err != nil {
+		return models.APIKey{}, err
+	}
+	return p.next.CreateAPIKey(ctx, data)
+}
+
+func (p *Usecase) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	if err := p.authorize(ctx, "GetAPIKeys"); err != nil {
+		return nil, err
+	}
+	return p.next.GetAPIKeys(ctx)
+}
+
+func (p *Usecase) RotateAPIKey(ctx context.Context, data models.RotateAPIKeyRequest) (models.APIKey, error) {
+	if err := p.authorize(ctx, "RotateAPIKey"); err != nil {
+		return models.APIKey{}, err
+	}
+	return p.next.RotateAPIKey(ctx, data)
+}
+
+func (p *Usecase) RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	if err := p.authorize(ctx, "RevokeAPIKey"); err != nil {
+		return models.APIKey{}, err
+	}
+	return p.next.RevokeAPIKey(ctx, id)
+}
+
+func (p *Usecase) GetAPIKeyUsage(ctx context.Context, data models.GetAPIKeyUsageRequest) ([]models.APIKeyUsage, error) {
+	if err := p.authorize(ctx, "GetAPIKeyUsage"); err != nil {
+		return nil, err
+	}
+	return p.next.GetAPIKeyUsage(ctx, data)
+}
+
+func (p *Usecase) AuthenticateAPIKey(ctx context.Context, key string) (models.APIKey, error) {
+	if err := p.authorize(ctx, "AuthenticateAPIKey"); err != nil {
+		return models.APIKey{}, err
+	}
+	return p.next.AuthenticateAPIKey(ctx, key)
+}
+
+func (p *Usecase) RecordAPIKeyUsage(ctx context.Context, data models.APIKeyUsage) error {
+	if err := p.authorize(ctx, "RecordAPIKeyUsage"); err != nil {
+		return err
+	}
+	return p.next.RecordAPIKeyUsage(ctx, data)
+}
+
+func (p *Usecase) BootstrapAPIKey(ctx context.Context, key string) error {
+	if err := p.authorize(ctx, "BootstrapAPIKey"); err != nil {
+		return err
+	}
+	return p.next.BootstrapAPIKey(ctx, key)
+}
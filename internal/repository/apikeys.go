@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+func (r *pgRepo) CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	res, err := scanAPIKey(r.db.QueryRowContext(ctx, queryInsertAPIKey,
+		key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.Role, key.RotatedFrom, key.ExpiresAt,
+	))
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.CreateAPIKey")
+		return models.APIKey{}, err
+	}
+	res.Key = key.Key
+	return res, nil
+}
+
+func (r *pgRepo) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetAPIKeys)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetAPIKeys")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			err := errors.Wrap(err, "pgRepo.GetAPIKeys")
+			return nil, err
+		}
+		res = append(res, key)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetAPIKeys")
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	res, err := scanAPIKey(r.db.QueryRowContext(ctx, queryGetAPIKeyByHash, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errors.Wrap(models.ErrAPIKeyNotFound, "pgRepo.GetAPIKeyByHash")
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.GetAPIKeyByHash")
+	}
+	return res, nil
+}
+
+// RotateAPIKey stores next as the successor of the key id and lets the old
+// key expire at oldExpiresAt, unless it expires earlier anyway.
+func (r *pgRepo) RotateAPIKey(ctx context.Context, id uuid.UUID, next models.APIKey, oldExpiresAt time.Time) (models.APIKey, error) {
+	var res models.APIKey
+	err := r.inTx(ctx, "rotate_api_key", func(tx *sql.Tx) error {
+		old, err := scanAPIKey(tx.QueryRowContext(ctx, queryLockAPIKey, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrAPIKeyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !old.Active(time.Now()) {
+			return models.ErrAPIKeyRevoked
+		}
+
+		rotatedFrom := old.ID
+		res, err = scanAPIKey(tx.QueryRowContext(ctx, queryInsertAPIKey,
+			old.Name, next.Prefix, next.KeyHash, pq.Array(old.Scopes), old.Role, rotatedFrom, old.ExpiresAt,
+		))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, queryExpireAPIKey, id, oldExpiresAt)
+		return err
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.RotateAPIKey")
+		return models.APIKey{}, err
+	}
+	res.Key = next.Key
+	return res, nil
+}
+
+func (r *pgRepo) RevokeAPIKey(ctx context.Context, id uuid.UUID) (models.APIKey, error) {
+	res, err := scanAPIKey(r.db.QueryRowContext(ctx, queryRevokeAPIKey, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errors.Wrap(models.ErrAPIKeyNotFound, "pgRepo.RevokeAPIKey")
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.RevokeAPIKey")
+	}
+	return res, nil
+}
+
+// RecordAPIKeyUsage adds usage to the audit log of its key and updates the
+// key's last use.
+func (r *pgRepo) RecordAPIKeyUsage(ctx context.Context, usage models.APIKeyUsage) error {
+	_, err := r.db.ExecContext(ctx, queryInsertAPIKeyUsage,
+		usage.KeyID, usage.Method, usage.Route, usage.Status, usage.ClientIP, usage.CreatedAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, "pgRepo.RecordAPIKeyUsage")
+	}
+	return nil
+}
+
+func (r *pgRepo) GetAPIKeyUsage(ctx context.Context, id uuid.UUID, limit int) ([]models.APIKeyUsage, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetAPIKeyUsage, id, limit)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetAPIKeyUsage")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.APIKeyUsage{}
+	for rows.Next() {
+		usage := models.APIKeyUsage{KeyID: id.String()}
+		if err := rows.Scan(&usage.Method, &usage.Route, &usage.Status, &usage.ClientIP, &usage.CreatedAt); err != nil {
+			err := errors.Wrap(err, "pgRepo.GetAPIKeyUsage")
+			return nil, err
+		}
+		res = append(res, usage)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetAPIKeyUsage")
+		return nil, err
+	}
+	return res, nil
+}
+
+func scanAPIKey(row scanner) (models.APIKey, error) {
+	var res models.APIKey
+	err := row.Scan(
+		&res.ID, &res.Name, &res.Prefix, &res.KeyHash, pq.Array(&res.Scopes), &res.Role, &res.RotatedFrom,
+		&res.ExpiresAt, &res.RevokedAt, &res.LastUsedAt, &res.CreatedAt,
+	)
+	return res, err
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// SetCreditLimit lets the wallet balance go negative down to -creditLimit.
+// Funds reserved by holds count against the credit line, so the limit cannot
+// be lowered below the credit already in use.
+func (r *pgRepo) SetCreditLimit(ctx context.Context, id uuid.UUID, creditLimit int64) (models.Wallet, error) {
+	var res models.Wallet
+	err := r.inTx(ctx, "set_credit_limit", func(tx *sql.Tx) error {
+		var err error
+		res, err = scanWallet(tx.QueryRowContext(ctx, queryUpdateCreditLimit, id, creditLimit))
+		if !errors.Is(err, models.ErrWalletNotFound) {
+			return err
+		}
+
+		var balance, held int64
+		err = tx.QueryRowContext(ctx, queryLockWalletStatus, id).Scan(new(string), &balance, &held)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return errors.Wrapf(models.ErrInvalidRequest, "credit limit %d is below the %d in use", creditLimit, held-balance)
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.SetCreditLimit")
+		return models.Wallet{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+func (r *pgRepo) CreateQuote(ctx context.Context, quote models.Quote) (models.Quote, error) {
+	res := quote
+	err := r.db.QueryRowContext(ctx, queryInsertQuote,
+		quote.FromCurrency, quote.ToCurrency, quote.Rate, quote.Amount, quote.ConvertedAmount, quote.ExpiresAt,
+	).Scan(&res.ID, &res.CreatedAt)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.CreateQuote")
+		return models.Quote{}, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) GetQuote(ctx context.Context, id uuid.UUID) (models.Quote, error) {
+	var res models.Quote
+	err := r.db.QueryRowContext(ctx, queryGetQuote, id).Scan(
+		&res.ID, &res.FromCurrency, &res.ToCurrency, &res.Rate, &res.Amount,
+		&res.ConvertedAmount, &res.ExpiresAt, &res.UsedAt, &res.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		err := errors.Wrap(models.ErrQuoteNotFound, "pgRepo.GetQuote")
+		return res, err
+	}
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetQuote")
+		return res, err
+	}
+	return res, nil
+}
+
+// useQuote marks the quote as used, failing if it has expired or was used
+// by another transfer.
+func useQuote(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	result, err := tx.ExecContext(ctx, queryUseQuote, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected < 1 {
+		return models.ErrQuoteExpired
+	}
+	return nil
+}
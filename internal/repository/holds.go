@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// Holds reserve part of a wallet balance. Reserved funds are tracked in
+// wallets.held and excluded from the available balance until the hold is
+// captured, voided or expires. A hold is checked against the withdrawal
+// limits when it is created, so its capture is not checked again. Frozen
+// wallets can neither take new holds nor have existing ones captured; those
+// holds can only be voided or left to expire.
+
+func (r *pgRepo) CreateHold(ctx context.Context, walletID uuid.UUID, amount int64, expiresAt time.Time) (models.Hold, error) {
+	var res models.Hold
+	err := r.inTx(ctx, "create_hold", func(tx *sql.Tx) error {
+		if err := checkLimits(ctx, tx, walletID, amount, true); err != nil {
+			return err
+		}
+		if err := reserveFunds(ctx, tx, walletID, amount); err != nil {
+			return err
+		}
+		return scanHold(tx.QueryRowContext(ctx, queryInsertHold, walletID, amount, expiresAt), &res)
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.CreateHold")
+		return models.Hold{}, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) GetHold(ctx context.Context, id uuid.UUID) (models.Hold, error) {
+	var res models.Hold
+	err := scanHold(r.db.QueryRowContext(ctx, queryGetHold, id), &res)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetHold")
+		return models.Hold{}, err
+	}
+	return res, nil
+}
+
+// CaptureHold releases the whole reservation and debits amount, which may be
+// less than the held amount, from the wallet. The remainder becomes
+// available again. The debit goes through the same status guard as a
+// withdrawal, so capturing on a frozen or closed wallet fails.
+func (r *pgRepo) CaptureHold(ctx context.Context, id uuid.UUID, amount int64) (models.CaptureHoldResponse, error) {
+	var res models.CaptureHoldResponse
+	err := r.inTx(ctx, "capture_hold", func(tx *sql.Tx) error {
+		hold, err := lockActiveHold(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if amount > hold.Amount {
+			return errors.Wrapf(models.ErrInvalidAmount, "capture of %d exceeds held %d", amount, hold.Amount)
+		}
+
+		walletID := uuid.MustParse(hold.WalletID)
+		if err := releaseFunds(ctx, tx, walletID, hold.Amount); err != nil {
+			return err
+		}
+
+		journalEntryID, err := createJournalEntry(ctx, tx, models.OperationCapture)
+		if err != nil {
+			return err
+		}
+		res.Transaction, err = applyTransaction(ctx, tx, ledgerEntry{
+			operation:      models.OperationCapture,
+			walletID:       walletID,
+			amount:         amount,
+			debit:          true,
+			holdID:         uuid.NullUUID{UUID: id, Valid: true},
+			journalEntryID: journalEntryID,
+			counterpart:    accountCashOut,
+		})
+		if err != nil {
+			return err
+		}
+
+		hold.Status = models.HoldStatusCaptured
+		hold.CapturedAmount = amount
+		res.Hold = hold
+		return tx.QueryRowContext(ctx, queryUpdateHoldStatus, id, hold.Status, amount).Scan(&res.Hold.UpdatedAt)
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.CaptureHold")
+		return models.CaptureHoldResponse{}, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) VoidHold(ctx context.Context, id uuid.UUID) (models.Hold, error) {
+	var res models.Hold
+	err := r.inTx(ctx, "void_hold", func(tx *sql.Tx) error {
+		hold, err := lockActiveHold(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := releaseFunds(ctx, tx, uuid.MustParse(hold.WalletID), hold.Amount); err != nil {
+			return err
+		}
+
+		hold.Status = models.HoldStatusVoided
+		res = hold
+		return tx.QueryRowContext(ctx, queryUpdateHoldStatus, id, hold.Status, 0).Scan(&res.UpdatedAt)
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.VoidHold")
+		return models.Hold{}, err
+	}
+	return res, nil
+}
+
+// ExpireHolds marks every active hold past its expiry as expired and
+// releases the reserved funds. It returns the number of wallets affected.
+func (r *pgRepo) ExpireHolds(ctx context.Context) (int64, error) {
+	var released int64
+	err := r.inTx(ctx, "expire_holds", func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, queryExpireHolds)
+		if err != nil {
+			return err
+		}
+		released, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.ExpireHolds")
+		return 0, err
+	}
+	if released > 0 {
+		logrus.WithField("wallets", released).Info("Released expired holds")
+	}
+	return released, nil
+}
+
+// lockActiveHold locks the hold row and checks that it can still be
+// captured or voided.
+func lockActiveHold(ctx context.Context, tx *sql.Tx, id uuid.UUID) (models.Hold, error) {
+	var hold models.Hold
+	if err := scanHold(tx.QueryRowContext(ctx, queryLockHold, id), &hold); err != nil {
+		return hold, err
+	}
+	if hold.Status != models.HoldStatusActive {
+		return hold, errors.Wrapf(models.ErrHoldNotActive, "hold is %s", hold.Status)
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		return hold, errors.Wrap(models.ErrHoldNotActive, "hold has expired")
+	}
+	return hold, nil
+}
+
+func reserveFunds(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, amount int64) error {
+	result, err := tx.ExecContext(ctx, queryReserveFunds, walletID, amount)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected < 1 {
+		return walletNotUpdatedError(ctx, tx, walletID, amount, true)
+	}
+	return nil
+}
+
+func releaseFunds(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, amount int64) error {
+	result, err := tx.ExecContext(ctx, queryReleaseFunds, walletID, amount)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected < 1 {
+		return errors.Errorf("wallet %s holds less than %d", walletID, amount)
+	}
+	return nil
+}
+
+func scanHold(row *sql.Row, hold *models.Hold) error {
+	err := row.Scan(
+		&hold.ID, &hold.WalletID, &hold.Amount, &hold.CapturedAmount,
+		&hold.Status, &hold.ExpiresAt, &hold.CreatedAt, &hold.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrHoldNotFound
+	}
+	return err
+}
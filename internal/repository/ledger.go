@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// System accounts are the counterparties of money entering and leaving the
+// service. Postings are signed from the account's point of view: a positive
+// amount increases its balance. Wallets are credited against CASH_IN on
+// deposit and debited against CASH_OUT on withdrawal, so every journal entry
+// nets to zero per currency. Both legs of a cross-currency transfer are
+// posted against the FX account of their currency.
+const (
+	accountCashIn  = "CASH_IN"
+	accountCashOut = "CASH_OUT"
+	accountFees    = "FEES"
+	accountFX      = "FX"
+
+	journalKindTransfer = "TRANSFER"
+)
+
+var systemAccounts = []string{accountCashIn, accountCashOut, accountFees, accountFX}
+
+func createJournalEntry(ctx context.Context, tx *sql.Tx, kind string) (uuid.UUID, error) {
+	var id uuid.UUID
+	if err := tx.QueryRowContext(ctx, queryInsertJournalEntry, kind).Scan(&id); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// postToWallet posts amount to the wallet account and returns its currency.
+func postToWallet(ctx context.Context, tx *sql.Tx, entryID, walletID uuid.UUID, amount int64) (string, error) {
+	var currency string
+	err := tx.QueryRowContext(ctx, queryInsertWalletPosting, entryID, walletID, amount).Scan(&currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.Errorf("no ledger account for wallet %s", walletID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+func postToSystem(ctx context.Context, tx *sql.Tx, entryID uuid.UUID, code, currency string, amount int64) error {
+	result, err := tx.ExecContext(ctx, queryInsertSystemPosting, entryID, code, currency, amount)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected < 1 {
+		return errors.Errorf("no %s system account for %s", code, currency)
+	}
+	return nil
+}
+
+// openAccounts creates the ledger account of a new wallet together with the
+// system accounts of its currency, if they do not exist yet.
+func openAccounts(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, currency string) error {
+	if _, err := tx.ExecContext(ctx, queryEnsureSystemAccounts, pq.Array(systemAccounts), currency); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, queryInsertWalletAccount, walletID, currency); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *pgRepo) ReconcileWallet(ctx context.Context, id uuid.UUID) (models.Reconciliation, error) {
+	res := models.Reconciliation{WalletID: id.String()}
+	err := r.db.QueryRowContext(ctx, queryReconcileWallet, id).Scan(&res.Balance, &res.LedgerBalance)
+	if errors.Is(err, sql.ErrNoRows) {
+		err := errors.Wrap(models.ErrWalletNotFound, "pgRepo.ReconcileWallet")
+		return res, err
+	}
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.ReconcileWallet")
+		return res, err
+	}
+
+	res.Difference = res.Balance - res.LedgerBalance
+	res.Balanced = res.Difference == 0
+	return res, nil
+}
+
+func (r *pgRepo) GetTrialBalance(ctx context.Context) (models.TrialBalance, error) {
+	rows, err := r.db.QueryContext(ctx, queryTrialBalance)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetTrialBalance")
+		return models.TrialBalance{}, err
+	}
+	defer rows.Close()
+
+	res := models.TrialBalance{Lines: []models.TrialBalanceLine{}, Balanced: true}
+	totals := make(map[string]int64)
+	for rows.Next() {
+		var line models.TrialBalanceLine
+		if err := rows.Scan(&line.Account, &line.Currency, &line.Balance); err != nil {
+			err := errors.Wrap(err, "pgRepo.GetTrialBalance")
+			return models.TrialBalance{}, err
+		}
+		totals[line.Currency] += line.Balance
+		res.Lines = append(res.Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetTrialBalance")
+		return models.TrialBalance{}, err
+	}
+
+	for _, total := range totals {
+		if total != 0 {
+			res.Balanced = false
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+var (
+	// withdrawalOperations count towards the withdrawal caps.
+	withdrawalOperations = []string{models.OperationWithdraw, models.OperationTransferOut, models.OperationCapture}
+	// limitedOperations count towards the operations limit.
+	limitedOperations = []string{models.OperationDeposit, models.OperationWithdraw, models.OperationTransferOut, models.OperationCapture}
+)
+
+func (r *pgRepo) SetWalletTier(ctx context.Context, id uuid.UUID, tier string) (models.Wallet, error) {
+	res, err := scanWallet(r.db.QueryRowContext(ctx, queryUpdateWalletTier, id, tier))
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.SetWalletTier")
+		return models.Wallet{}, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) SetWalletLimits(ctx context.Context, id uuid.UUID, limits models.Limits) error {
+	_, err := r.db.ExecContext(ctx, queryUpsertWalletLimits, append([]any{id}, limitArgs(limits)...)...)
+	if isForeignKeyViolation(err) {
+		return errors.Wrap(models.ErrWalletNotFound, "pgRepo.SetWalletLimits")
+	}
+	if err != nil {
+		return errors.Wrap(err, "pgRepo.SetWalletLimits")
+	}
+	return nil
+}
+
+func (r *pgRepo) SetTierLimits(ctx context.Context, tier string, limits models.Limits) error {
+	_, err := r.db.ExecContext(ctx, queryUpsertTierLimits, append([]any{tier}, limitArgs(limits)...)...)
+	if err != nil {
+		return errors.Wrap(err, "pgRepo.SetTierLimits")
+	}
+	return nil
+}
+
+// checkLimits locks the wallet and evaluates a new transaction of amount
+// against the limits in effect for it. Usage is read under the lock, so
+// concurrent transactions on the wallet are counted.
+func checkLimits(ctx context.Context, tx *sql.Tx, id uuid.UUID, amount int64, debit bool) error {
+	wallet, err := scanWallet(tx.QueryRowContext(ctx, queryLockWallet, id))
+	if err != nil {
+		return err
+	}
+
+	var usage models.LimitUsage
+	if wallet.Limits.NeedsUsage(debit) {
+		if usage, err = getLimitUsage(ctx, tx, id, wallet.Limits.Windows(time.Now())); err != nil {
+			return err
+		}
+	}
+	return wallet.Limits.Check(usage, amount, debit)
+}
+
+func getLimitUsage(ctx context.Context, tx *sql.Tx, id uuid.UUID, windows models.LimitWindows) (models.LimitUsage, error) {
+	var res models.LimitUsage
+	var available sql.NullInt64
+	err := tx.QueryRowContext(ctx, queryGetLimitUsage,
+		id, pq.Array(withdrawalOperations), pq.Array(limitedOperations), windows.Day, windows.Month, windows.Operations,
+	).Scan(&available, &res.WithdrawnToday, &res.WithdrawnThisMonth, &res.Operations)
+	if err != nil {
+		return res, err
+	}
+	if !available.Valid {
+		return res, models.ErrWalletNotFound
+	}
+	res.Available = available.Int64
+	return res, nil
+}
+
+func limitArgs(limits models.Limits) []any {
+	return []any{
+		limits.MaxSingleAmount, limits.DailyWithdrawalCap, limits.MonthlyWithdrawalCap,
+		limits.MaxOperations, limits.OperationsWindowSeconds, limits.MinBalance,
+	}
+}
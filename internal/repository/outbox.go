@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// insertBalanceChangedEvent records the balance change made by t in the
+// outbox within the same transaction and queues it for the webhook endpoints
+// subscribed to it.
+func insertBalanceChangedEvent(ctx context.Context, tx *sql.Tx, t models.Transaction, currency string) error {
+	payload, err := json.Marshal(models.BalanceChangedEvent{
+		TransactionID:         t.ID,
+		WalletID:              t.WalletID,
+		Operation:             t.Operation,
+		Amount:                t.Amount,
+		Currency:              currency,
+		BalanceAfter:          t.Balance,
+		TransferID:            t.TransferID,
+		HoldID:                t.HoldID,
+		OriginalTransactionID: t.OriginalTransactionID,
+		CreatedAt:             t.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	var eventID int64
+	err = tx.QueryRowContext(ctx, queryInsertOutboxEvent, models.EventBalanceChanged, t.WalletID, payload).Scan(&eventID)
+	if err != nil {
+		return err
+	}
+
+	eventType, ok := models.WebhookEventTypes[t.Operation]
+	if !ok {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, queryInsertWebhookDeliveries, eventID, eventType, t.WalletID, payload)
+	return err
+}
+
+// ClaimOutboxEvents returns up to limit due events in order and hides them
+// from other relays for lease. Events are locked with SKIP LOCKED, so several
+// relays can run side by side.
+func (r *pgRepo) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	var res []models.OutboxEvent
+	err := r.inTx(ctx, "claim_outbox_events", func(tx *sql.Tx) error {
+		res = nil
+		rows, err := tx.QueryContext(ctx, queryClaimOutboxEvents, limit, lease.Seconds())
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var event models.OutboxEvent
+			var payload []byte
+			if err := rows.Scan(&event.ID, &event.Type, &event.AggregateID, &payload, &event.Attempts, &event.CreatedAt); err != nil {
+				return err
+			}
+			event.Payload = payload
+			res = append(res, event)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.ClaimOutboxEvents")
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, queryMarkOutboxEventPublished, id); err != nil {
+		return errors.Wrap(err, "pgRepo.MarkOutboxEventPublished")
+	}
+	return nil
+}
+
+// RecordOutboxFailure stores a failed publish of the event and schedules the
+// next attempt at nextAttemptAt, or dead-letters the event when dead is set.
+func (r *pgRepo) RecordOutboxFailure(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	if _, err := r.db.ExecContext(ctx, queryRecordOutboxFailure, id, lastError, nextAttemptAt, dead); err != nil {
+		return errors.Wrap(err, "pgRepo.RecordOutboxFailure")
+	}
+	return nil
+}
+
+func (r *pgRepo) GetOutboxLag(ctx context.Context) (models.OutboxLag, error) {
+	var res models.OutboxLag
+	var seconds float64
+	if err := r.db.QueryRowContext(ctx, queryGetOutboxLag).Scan(&res.Pending, &seconds, &res.Dead); err != nil {
+		return res, errors.Wrap(err, "pgRepo.GetOutboxLag")
+	}
+	res.OldestAge = time.Duration(seconds * float64(time.Second))
+	return res, nil
+}
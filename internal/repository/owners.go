@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+func (r *pgRepo) IsWalletOwner(ctx context.Context, id uuid.UUID, subject string) (bool, error) {
+	var owner bool
+	if err := r.db.QueryRowContext(ctx, queryIsWalletOwner, id, subject).Scan(&owner); err != nil {
+		return false, errors.Wrap(err, "pgRepo.IsWalletOwner")
+	}
+	return owner, nil
+}
+
+func (r *pgRepo) GetWalletOwners(ctx context.Context, id uuid.UUID) ([]models.WalletOwner, error) {
+	if _, err := r.GetWallet(ctx, id); err != nil {
+		return nil, errors.Wrap(err, "pgRepo.GetWalletOwners")
+	}
+
+	rows, err := r.db.QueryContext(ctx, queryGetWalletOwners, id)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWalletOwners")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.WalletOwner{}
+	for rows.Next() {
+		var owner models.WalletOwner
+		if err := rows.Scan(&owner.WalletID, &owner.Subject, &owner.CreatedAt); err != nil {
+			err := errors.Wrap(err, "pgRepo.GetWalletOwners")
+			return nil, err
+		}
+		res = append(res, owner)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWalletOwners")
+		return nil, err
+	}
+	return res, nil
+}
+
+// AddWalletOwner is idempotent: adding an existing owner returns it unchanged.
+func (r *pgRepo) AddWalletOwner(ctx context.Context, id uuid.UUID, subject string) (models.WalletOwner, error) {
+	var res models.WalletOwner
+	err := r.db.QueryRowContext(ctx, queryInsertWalletOwner, id, subject).
+		Scan(&res.WalletID, &res.Subject, &res.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return res, errors.Wrap(models.ErrWalletNotFound, "pgRepo.AddWalletOwner")
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.AddWalletOwner")
+	}
+	return res, nil
+}
+
+func (r *pgRepo) RemoveWalletOwner(ctx context.Context, id uuid.UUID, subject string) error {
+	res, err := r.db.ExecContext(ctx, queryDeleteWalletOwner, id, subject)
+	if err != nil {
+		return errors.Wrap(err, "pgRepo.RemoveWalletOwner")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "pgRepo.RemoveWalletOwner")
+	}
+	if n == 0 {
+		return errors.Wrap(models.ErrOwnerNotFound, "pgRepo.RemoveWalletOwner")
+	}
+	return nil
+}
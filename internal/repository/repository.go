@@ -3,124 +3,534 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
+	"github.com/SerzhLimon/PaymentService/config"
 	"github.com/SerzhLimon/PaymentService/internal/models"
 )
 
 type Repository interface {
-	WalletTransactionDeposit(id uuid.UUID, amount int64) error
-	WalletTransactionWithdraw(id uuid.UUID, amount int64) error
-	GetBalance(id uuid.UUID) (models.GetBalanceResponse, error)
-	CreateWallet(id uuid.UUID) error
+	WalletTransactionDeposit(ctx context.Context, id uuid.UUID, amount int64, idem models.Idempotency) (models.Transaction, error)
+	WalletTransactionWithdraw(ctx context.Context, id uuid.UUID, amount int64, idem models.Idempotency) (models.Transaction, error)
+	Transfer(ctx context.Context, from, to uuid.UUID, amount int64, conv *models.Conversion) (models.Transfer, error)
+	GetBalance(ctx context.Context, id uuid.UUID) (models.GetBalanceResponse, error)
+	GetTransactions(ctx context.Context, filter models.TransactionFilter) ([]models.Transaction, error)
+	CreateWallet(ctx context.Context, wallet models.Wallet) (models.Wallet, error)
+	GetWallet(ctx context.Context, id uuid.UUID) (models.Wallet, error)
+	ChangeWalletStatus(ctx context.Context, id uuid.UUID, status string, blockDeposits bool, reason string) (models.Wallet, error)
+	GetWalletStatusHistory(ctx context.Context, id uuid.UUID) ([]models.WalletStatusChange, error)
+	SetWalletTier(ctx context.Context, id uuid.UUID, tier string) (models.Wallet, error)
+	SetCreditLimit(ctx context.Context, id uuid.UUID, creditLimit int64) (models.Wallet, error)
+	IsWalletOwner(ctx context.Context, id uuid.UUID, subject string) (bool, error)
+	GetWalletOwners(ctx context.Context, id uuid.UUID) ([]models.WalletOwner, error)
+	AddWalletOwner(ctx context.Context, id uuid.UUID, subject string) (models.WalletOwner, error)
+	RemoveWalletOwner(ctx context.Context, id uuid.UUID, subject string) error
+	SetWalletLimits(ctx context.Context, id uuid.UUID, limits models.Limits) error
+	SetTierLimits(ctx context.Context, tier string, limits models.Limits) error
+	CreateHold(ctx context.Context, walletID uuid.UUID, amount int64, expiresAt time.Time) (models.Hold, error)
+	GetHold(ctx context.Context, id uuid.UUID) (models.Hold, error)
+	CaptureHold(ctx context.Context, id uuid.UUID, amount int64) (models.CaptureHoldResponse, error)
+	VoidHold(ctx context.Context, id uuid.UUID) (models.Hold, error)
+	ExpireHolds(ctx context.Context) (int64, error)
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	RecordOutboxFailure(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error
+	GetOutboxLag(ctx context.Context) (models.OutboxLag, error)
+	CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) (models.WebhookEndpoint, error)
+	GetWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (models.WebhookEndpoint, error)
+	DisableWebhookEndpoint(ctx context.Context, id uuid.UUID) (models.WebhookEndpoint, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, attempt models.WebhookAttempt, status string, nextAttemptAt time.Time) error
+	GetWebhookDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error)
+	CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	RotateAPIKey(ctx context.Context, id uuid.UUID, next models.APIKey, oldExpiresAt time.Time) (models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (models.APIKey, error)
+	RecordAPIKeyUsage(ctx context.Context, usage models.APIKeyUsage) error
+	GetAPIKeyUsage(ctx context.Context, id uuid.UUID, limit int) ([]models.APIKeyUsage, error)
+	ReconcileWallet(ctx context.Context, id uuid.UUID) (models.Reconciliation, error)
+	GetTrialBalance(ctx context.Context) (models.TrialBalance, error)
+	CreateQuote(ctx context.Context, quote models.Quote) (models.Quote, error)
+	GetQuote(ctx context.Context, id uuid.UUID) (models.Quote, error)
+	ReverseTransaction(ctx context.Context, id uuid.UUID, amount int64) (models.Reversal, error)
 }
 
 type pgRepo struct {
-	db *sql.DB
+	db    *sql.DB
+	retry config.TxRetryConfig
 }
 
-func NewPGRepository(db *sql.DB) Repository {
-	return &pgRepo{db: db}
+func NewPGRepository(db *sql.DB, retry config.TxRetryConfig) Repository {
+	return &pgRepo{db: db, retry: retry}
 }
 
-func (r *pgRepo) WalletTransactionDeposit(id uuid.UUID, amount int64) error {
-	txOptions := &sql.TxOptions{
-		Isolation: sql.LevelSerializable,
-		ReadOnly:  false,
+func (r *pgRepo) WalletTransactionDeposit(ctx context.Context, id uuid.UUID, amount int64, idem models.Idempotency) (models.Transaction, error) {
+	res, err := r.walletTransaction(ctx, ledgerEntry{
+		operation:   models.OperationDeposit,
+		walletID:    id,
+		amount:      amount,
+		counterpart: accountCashIn,
+		idempotency: idem,
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.WalletTransactionDeposit")
+		return models.Transaction{}, err
 	}
+	return res, nil
+}
 
-	tx, err := r.db.BeginTx(context.Background(), txOptions)
+func (r *pgRepo) WalletTransactionWithdraw(ctx context.Context, id uuid.UUID, amount int64, idem models.Idempotency) (models.Transaction, error) {
+	res, err := r.walletTransaction(ctx, ledgerEntry{
+		operation:   models.OperationWithdraw,
+		walletID:    id,
+		amount:      amount,
+		debit:       true,
+		counterpart: accountCashOut,
+		idempotency: idem,
+	})
 	if err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionDeposit %v", err)
-		return err
+		err := errors.Wrap(err, "pgRepo.WalletTransactionWithdraw")
+		return models.Transaction{}, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
+	return res, nil
+}
+
+// walletTransaction applies a single-wallet ledger entry in its own
+// transaction. When the entry carries an idempotency key that has already
+// been used, the original transaction is returned instead of applying the
+// entry again; limits are only checked for new entries.
+func (r *pgRepo) walletTransaction(ctx context.Context, e ledgerEntry) (models.Transaction, error) {
+	var res models.Transaction
+	err := r.inTx(ctx, e.operation, func(tx *sql.Tx) error {
+		if e.idempotency.Key != "" {
+			replay, found, err := findIdempotentTransaction(ctx, tx, e.walletID, e.idempotency)
+			if err != nil || found {
+				res = replay
+				return err
+			}
 		}
-	}()
 
-	result, err := tx.Exec(queryWalletTransactionDeposit, id, amount)
-	if err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionDeposit %v", err)
+		if err := checkLimits(ctx, tx, e.walletID, e.amount, e.debit); err != nil {
+			return err
+		}
+
+		entry := e
+		var err error
+		if entry.journalEntryID, err = createJournalEntry(ctx, tx, e.operation); err != nil {
+			return err
+		}
+
+		res, err = applyTransaction(ctx, tx, entry)
 		return err
+	})
+	if isUniqueViolation(err, idempotencyKeyConstraint) {
+		// A concurrent request with the same key committed first.
+		replay, found, lookupErr := findIdempotentTransaction(ctx, r.db, e.walletID, e.idempotency)
+		switch {
+		case lookupErr != nil:
+			err = lookupErr
+		case found:
+			res, err = replay, nil
+		default:
+			// The winner is not visible yet; let the client retry.
+			err = fmt.Errorf("%w: %w", models.ErrConflict, err)
+		}
+	}
+	if err != nil {
+		return models.Transaction{}, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return res, nil
+}
+
+// findIdempotentTransaction looks up a transaction previously recorded on the
+// wallet with the same idempotency key and checks that it was made by the
+// same request.
+func findIdempotentTransaction(ctx context.Context, q querier, walletID uuid.UUID, idem models.Idempotency) (models.Transaction, bool, error) {
+	var (
+		res         models.Transaction
+		requestHash string
+	)
+	err := q.QueryRowContext(ctx, queryGetTransactionByIdempotencyKey, walletID, idem.Key).Scan(
+		&res.ID, &res.WalletID, &res.Operation, &res.Amount, &res.Balance, &res.TransferID, &res.HoldID,
+		&res.Status, &res.ReversedAmount, &res.OriginalTransactionID, &res.CreatedAt, &res.Currency, &requestHash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Transaction{}, false, nil
+	}
 	if err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionDeposit %v", err)
-		return err
+		return models.Transaction{}, false, err
 	}
-	if rowsAffected < 1 {
-		err := errors.Errorf("pgRepo.WalletTransactionDeposit: no rows affected")
-		return err
+	if requestHash != idem.RequestHash {
+		return models.Transaction{}, true, models.ErrIdempotencyConflict
 	}
 
-	if err := tx.Commit(); err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionDeposit %v", err)
+	res.Replayed = true
+	return res, true, nil
+}
+
+// Transfer moves amount from one wallet to another. Same-currency transfers
+// pass a nil conv and post both legs against each other; cross-currency
+// transfers credit conv.Amount and post each leg against the FX account of
+// its currency.
+func (r *pgRepo) Transfer(ctx context.Context, from, to uuid.UUID, amount int64, conv *models.Conversion) (models.Transfer, error) {
+	var res models.Transfer
+	err := r.inTx(ctx, "transfer", func(tx *sql.Tx) error {
+		currencies, err := lockWallets(ctx, tx, from, to)
+		if err != nil {
+			return err
+		}
+		if err := checkLimits(ctx, tx, from, amount, true); err != nil {
+			return err
+		}
+
+		res = models.Transfer{
+			FromWalletID: from.String(),
+			ToWalletID:   to.String(),
+			Amount:       amount,
+			FromCurrency: currencies[from],
+			ToCurrency:   currencies[to],
+			ToAmount:     amount,
+		}
+		var counterpart string
+		var rate sql.NullString
+		var quoteID uuid.NullUUID
+		if conv != nil {
+			if conv.FromCurrency != res.FromCurrency || conv.ToCurrency != res.ToCurrency {
+				return errors.Wrapf(models.ErrCurrencyMismatch, "conversion %s/%s does not match wallets %s/%s",
+					conv.FromCurrency, conv.ToCurrency, res.FromCurrency, res.ToCurrency)
+			}
+			if conv.QuoteID.Valid {
+				if err := useQuote(ctx, tx, conv.QuoteID.UUID); err != nil {
+					return err
+				}
+				ref := conv.QuoteID.UUID.String()
+				res.QuoteID = &ref
+				quoteID = conv.QuoteID
+			}
+			res.ToAmount = conv.Amount
+			res.Rate = &conv.Rate
+			rate = sql.NullString{String: conv.Rate, Valid: true}
+			counterpart = accountFX
+		} else if res.FromCurrency != res.ToCurrency {
+			return errors.Wrapf(models.ErrCurrencyMismatch, "cannot transfer %s to %s without conversion", res.FromCurrency, res.ToCurrency)
+		}
+
+		err = tx.QueryRowContext(ctx, queryInsertTransfer, from, to, amount, res.FromCurrency, res.ToCurrency, res.ToAmount, rate, quoteID).
+			Scan(&res.ID, &res.CreatedAt)
+		if err != nil {
+			return err
+		}
+		transferID := uuid.NullUUID{UUID: uuid.MustParse(res.ID), Valid: true}
+
+		journalEntryID, err := createJournalEntry(ctx, tx, journalKindTransfer)
+		if err != nil {
+			return err
+		}
+
+		res.Debit, err = applyTransaction(ctx, tx, ledgerEntry{
+			operation:      models.OperationTransferOut,
+			walletID:       from,
+			amount:         amount,
+			debit:          true,
+			journalEntryID: journalEntryID,
+			transferID:     transferID,
+			counterpart:    counterpart,
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Credit, err = applyTransaction(ctx, tx, ledgerEntry{
+			operation:      models.OperationTransferIn,
+			walletID:       to,
+			amount:         res.ToAmount,
+			journalEntryID: journalEntryID,
+			transferID:     transferID,
+			counterpart:    counterpart,
+		})
 		return err
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.Transfer")
+		return models.Transfer{}, err
 	}
 
-	return nil
+	return res, nil
 }
 
-func (r *pgRepo) WalletTransactionWithdraw(id uuid.UUID, amount int64) error {
-	txOptions := &sql.TxOptions{
-		Isolation: sql.LevelSerializable,
-		ReadOnly:  false,
+// lockWallets takes row locks on both wallets in id order, so concurrent
+// transfers between the same pair never wait on each other in a cycle. It
+// returns the currency of each wallet.
+func lockWallets(ctx context.Context, tx *sql.Tx, a, b uuid.UUID) (map[uuid.UUID]string, error) {
+	rows, err := tx.QueryContext(ctx, queryLockWallets, a, b)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	tx, err := r.db.BeginTx(context.Background(), txOptions)
+	currencies := make(map[uuid.UUID]string, 2)
+	for rows.Next() {
+		var id uuid.UUID
+		var currency string
+		if err := rows.Scan(&id, &currency); err != nil {
+			return nil, err
+		}
+		currencies[id] = currency
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(currencies) != 2 {
+		return nil, models.ErrWalletNotFound
+	}
+
+	return currencies, nil
+}
+
+// ledgerEntry describes a single balance change, the transaction row
+// recording it and its side of the journal entry.
+type ledgerEntry struct {
+	operation   string
+	walletID    uuid.UUID
+	amount      int64
+	debit       bool
+	transferID  uuid.NullUUID
+	holdID      uuid.NullUUID
+	originalID  uuid.NullUUID
+	idempotency models.Idempotency
+
+	journalEntryID uuid.UUID
+	// counterpart is the system account that takes the opposite posting.
+	// It is empty when another wallet in the same journal entry does.
+	counterpart string
+}
+
+// applyTransaction updates the wallet balance, records the transaction row,
+// posts the movement to the journal entry and adds a balance change event to
+// the outbox, all within tx.
+func applyTransaction(ctx context.Context, tx *sql.Tx, e ledgerEntry) (models.Transaction, error) {
+	res := models.Transaction{
+		WalletID:  e.walletID.String(),
+		Operation: e.operation,
+		Amount:    e.amount,
+	}
+	if e.transferID.Valid {
+		ref := e.transferID.UUID.String()
+		res.TransferID = &ref
+	}
+	if e.holdID.Valid {
+		ref := e.holdID.UUID.String()
+		res.HoldID = &ref
+	}
+	if e.originalID.Valid {
+		ref := e.originalID.UUID.String()
+		res.OriginalTransactionID = &ref
+	}
+
+	query, delta := queryWalletTransactionDeposit, e.amount
+	if e.debit {
+		query, delta = queryWalletTransactionWithdraw, -e.amount
+	}
+
+	err := tx.QueryRowContext(ctx, query, e.walletID, e.amount).Scan(&res.Balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, walletNotUpdatedError(ctx, tx, e.walletID, e.amount, e.debit)
+	}
 	if err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionWithdraw %v", err)
-		return err
+		return res, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
 
-	result, err := tx.Exec(queryWalletTransactionWithdraw, id, amount)
+	err = tx.QueryRowContext(ctx, queryInsertTransaction,
+		e.walletID, e.operation, e.amount, res.Balance, e.transferID, e.idempotency.Key, e.idempotency.RequestHash, e.journalEntryID, e.holdID, e.originalID,
+	).Scan(&res.ID, &res.Status, &res.CreatedAt)
 	if err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionWithdraw %v", err)
-		return err
+		return res, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	currency, err := postToWallet(ctx, tx, e.journalEntryID, e.walletID, delta)
 	if err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionWithdraw %v", err)
-		return err
+		return res, err
 	}
-	if rowsAffected < 1 {
-		err := errors.Errorf("pgRepo.WalletTransactionWithdraw: no rows affected")
-		return err
+	res.Currency = currency
+	if e.counterpart != "" {
+		if err := postToSystem(ctx, tx, e.journalEntryID, e.counterpart, currency, -delta); err != nil {
+			return res, err
+		}
+	}
+
+	if err := insertBalanceChangedEvent(ctx, tx, res, currency); err != nil {
+		return res, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		err := errors.Errorf("pgRepo.WalletTransactionWithdraw %v", err)
+	return res, nil
+}
+
+// walletNotUpdatedError explains why a guarded balance update matched no
+// rows: the wallet does not exist, its status does not allow the movement or
+// it cannot cover a debit. A wallet with a credit line reports the credit
+// line as exhausted.
+func walletNotUpdatedError(ctx context.Context, tx *sql.Tx, id uuid.UUID, amount int64, debit bool) error {
+	var (
+		status          string
+		depositsBlocked bool
+		available       int64
+		creditLimit     int64
+	)
+	err := tx.QueryRowContext(ctx, queryGetWalletGuards, id).Scan(&status, &depositsBlocked, &available, &creditLimit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrWalletNotFound
+	}
+	if err != nil {
 		return err
 	}
 
-	return nil
+	switch {
+	case status == models.WalletStatusClosed:
+		return errors.Wrapf(models.ErrWalletClosed, "wallet %s", id)
+	case status == models.WalletStatusFrozen && (debit || depositsBlocked):
+		return errors.Wrapf(models.ErrWalletFrozen, "wallet %s", id)
+	case !debit || available >= amount:
+		return errors.New("no rows affected")
+	case creditLimit > 0:
+		return &models.CreditLimitExceededError{Available: available, CreditLimit: creditLimit}
+	}
+	return &models.InsufficientFundsError{Available: available}
 }
 
-func (r *pgRepo) GetBalance(id uuid.UUID) (models.GetBalanceResponse, error) {
+func (r *pgRepo) GetBalance(ctx context.Context, id uuid.UUID) (models.GetBalanceResponse, error) {
 	var res models.GetBalanceResponse
-	if err := r.db.QueryRow(queryGetBalance, id).Scan(&res.Amount); err != nil {
-		err := errors.Errorf("pgRepo.GetBalance %v", err)
+	err := r.db.QueryRowContext(ctx, queryGetBalance, id).
+		Scan(&res.Currency, &res.Ledger, &res.Available, &res.CreditLimit, &res.CreditUsed)
+	if errors.Is(err, sql.ErrNoRows) {
+		err := errors.Wrap(models.ErrWalletNotFound, "pgRepo.GetBalance")
+		return res, err
+	}
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetBalance")
 		return res, err
 	}
 	return res, nil
 }
 
-func (r *pgRepo) CreateWallet(id uuid.UUID) error {
-	_, err := r.db.Exec(queryCreateWallet, id)
+func (r *pgRepo) GetTransactions(ctx context.Context, filter models.TransactionFilter) ([]models.Transaction, error) {
+	query, args := buildTransactionsQuery(filter)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		err := errors.Errorf("pgRepo.CreateWallet %v", err)
-		return err
+		err := errors.Wrap(err, "pgRepo.GetTransactions")
+		return nil, err
 	}
-	return  nil
+	defer rows.Close()
+
+	res := make([]models.Transaction, 0, filter.Limit)
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(
+			&t.ID, &t.WalletID, &t.Operation, &t.Amount, &t.Balance, &t.TransferID, &t.HoldID,
+			&t.Status, &t.ReversedAmount, &t.OriginalTransactionID, &t.CreatedAt, &t.Currency,
+		); err != nil {
+			err := errors.Wrap(err, "pgRepo.GetTransactions")
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetTransactions")
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// buildTransactionsQuery appends the optional filters, keyset cursor and
+// ordering to queryGetTransactions.
+func buildTransactionsQuery(filter models.TransactionFilter) (string, []any) {
+	query := queryGetTransactions
+	args := []any{filter.WalletID}
+
+	where := func(cond string, values ...any) {
+		placeholders := make([]any, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			placeholders[i] = len(args)
+		}
+		query += " AND " + fmt.Sprintf(cond, placeholders...)
+	}
+
+	if filter.Operation != "" {
+		where("operation = $%d", filter.Operation)
+	}
+	if filter.MinAmount != nil {
+		where("amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		where("amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		where("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("created_at < $%d", *filter.To)
+	}
+
+	order := "ASC"
+	if filter.Descending {
+		order = "DESC"
+	}
+	if filter.After != nil {
+		cmp := ">"
+		if filter.Descending {
+			cmp = "<"
+		}
+		where("(created_at, id) "+cmp+" ($%d, $%d)", filter.After.CreatedAt, filter.After.ID)
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", order, order, len(args))
+
+	return query, args
+}
+
+func (r *pgRepo) GetWallet(ctx context.Context, id uuid.UUID) (models.Wallet, error) {
+	res, err := scanWallet(r.db.QueryRowContext(ctx, queryGetWallet, id))
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWallet")
+		return models.Wallet{}, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) CreateWallet(ctx context.Context, wallet models.Wallet) (models.Wallet, error) {
+	var ownerRef string
+	if wallet.OwnerRef != nil {
+		ownerRef = *wallet.OwnerRef
+	}
+
+	res := wallet
+	err := r.inTx(ctx, "create_wallet", func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, queryCreateWallet, wallet.ID, ownerRef, wallet.Currency, wallet.Tier, []byte(wallet.Metadata)).
+			Scan(&res.Balance, &res.Status, &res.CreatedAt, &res.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return openAccounts(ctx, tx, uuid.MustParse(wallet.ID), wallet.Currency)
+	})
+	if isUniqueViolation(err, walletsPrimaryKey) {
+		err := errors.Wrap(models.ErrWalletExists, "pgRepo.CreateWallet")
+		return models.Wallet{}, err
+	}
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.CreateWallet")
+		return models.Wallet{}, err
+	}
+	return res, nil
 }
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// reversalRule describes how to compensate an operation: the compensating
+// operation, whether it debits the wallet and the system account it posts
+// against. Operations without a rule cannot be reversed.
+type reversalRule struct {
+	operation   string
+	debit       bool
+	counterpart string
+}
+
+var reversalRules = map[string]reversalRule{
+	models.OperationDeposit:  {operation: models.OperationReversal, debit: true, counterpart: accountCashIn},
+	models.OperationWithdraw: {operation: models.OperationRefund, counterpart: accountCashOut},
+	models.OperationCapture:  {operation: models.OperationRefund, counterpart: accountCashOut},
+}
+
+// ReverseTransaction posts a compensating transaction for amount of the
+// original, or for everything not yet reversed when amount is 0. The wallet
+// status applies as to any other movement: a reversal cannot debit a frozen
+// wallet and a refund cannot credit a closed one.
+func (r *pgRepo) ReverseTransaction(ctx context.Context, id uuid.UUID, amount int64) (models.Reversal, error) {
+	var res models.Reversal
+	err := r.inTx(ctx, "reverse_transaction", func(tx *sql.Tx) error {
+		original, err := lockTransaction(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		rule, ok := reversalRules[original.Operation]
+		if !ok {
+			return errors.Wrapf(models.ErrNotReversible, "%s transactions cannot be reversed", original.Operation)
+		}
+		remaining := original.Amount - original.ReversedAmount
+		if remaining == 0 {
+			return errors.Wrap(models.ErrNotReversible, "transaction is already reversed")
+		}
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return errors.Wrapf(models.ErrReversalExceeded, "requested %d, remaining %d", amount, remaining)
+		}
+
+		journalEntryID, err := createJournalEntry(ctx, tx, rule.operation)
+		if err != nil {
+			return err
+		}
+		res.Reversal, err = applyTransaction(ctx, tx, ledgerEntry{
+			operation:      rule.operation,
+			walletID:       uuid.MustParse(original.WalletID),
+			amount:         amount,
+			debit:          rule.debit,
+			originalID:     uuid.NullUUID{UUID: id, Valid: true},
+			journalEntryID: journalEntryID,
+			counterpart:    rule.counterpart,
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Original = original
+		return tx.QueryRowContext(ctx, queryUpdateTransactionReversal, id, amount).
+			Scan(&res.Original.Status, &res.Original.ReversedAmount)
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.ReverseTransaction")
+		return models.Reversal{}, err
+	}
+	return res, nil
+}
+
+func lockTransaction(ctx context.Context, tx *sql.Tx, id uuid.UUID) (models.Transaction, error) {
+	var t models.Transaction
+	err := tx.QueryRowContext(ctx, queryLockTransaction, id).Scan(
+		&t.ID, &t.WalletID, &t.Operation, &t.Amount, &t.Balance, &t.TransferID, &t.HoldID,
+		&t.Status, &t.ReversedAmount, &t.OriginalTransactionID, &t.CreatedAt, &t.Currency,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, models.ErrTransactionNotFound
+	}
+	return t, err
+}
@@ -1,26 +1,563 @@
 package repository
 
 const (
+	// Closed wallets take no credits and frozen ones only take them while
+	// deposits are not blocked. Only active wallets can be debited.
 	queryWalletTransactionDeposit = `
 		UPDATE wallets
 		SET balance = balance + $2, updated_at = now()
-		WHERE id = $1
+		WHERE id = $1 AND status <> 'CLOSED' AND NOT (status = 'FROZEN' AND deposits_blocked)
+		RETURNING balance
 	`
 
 	queryWalletTransactionWithdraw = `
 		UPDATE wallets
 		SET balance = balance - $2, updated_at = now()
+		WHERE id = $1 AND status = 'ACTIVE' AND balance - held + credit_limit >= $2
+		RETURNING balance
+	`
+
+	queryInsertTransaction = `
+		INSERT INTO transactions (wallet_id, operation, amount, balance_after, transfer_id, idempotency_key, request_hash, journal_entry_id, hold_id, original_transaction_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10)
+		RETURNING id, status, created_at
+	`
+
+	queryGetTransactionByIdempotencyKey = `
+		SELECT id, wallet_id, operation, amount, balance_after, transfer_id, hold_id,
+			status, reversed_amount, original_transaction_id, created_at,
+			(SELECT currency FROM wallets WHERE wallets.id = transactions.wallet_id), request_hash
+		FROM transactions
+		WHERE wallet_id = $1 AND idempotency_key = $2
+	`
+
+	queryLockTransaction = `
+		SELECT id, wallet_id, operation, amount, balance_after, transfer_id, hold_id,
+			status, reversed_amount, original_transaction_id, created_at,
+			(SELECT currency FROM wallets WHERE wallets.id = transactions.wallet_id)
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	queryUpdateTransactionReversal = `
+		UPDATE transactions
+		SET reversed_amount = reversed_amount + $2,
+			status = CASE WHEN reversed_amount + $2 = amount THEN 'REVERSED' ELSE 'PARTIALLY_REVERSED' END
+		WHERE id = $1
+		RETURNING status, reversed_amount
+	`
+
+	queryLockWallets = `
+		SELECT id, currency
+		FROM wallets
+		WHERE id IN ($1, $2)
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	queryInsertTransfer = `
+		INSERT INTO transfers (from_wallet_id, to_wallet_id, amount, from_currency, to_currency, to_amount, rate, quote_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	queryGetTransactions = `
+		SELECT id, wallet_id, operation, amount, balance_after, transfer_id, hold_id,
+			status, reversed_amount, original_transaction_id, created_at,
+			(SELECT currency FROM wallets WHERE wallets.id = transactions.wallet_id)
+		FROM transactions
+		WHERE wallet_id = $1
+	`
+
+	queryGetWalletGuards = `
+		SELECT status, deposits_blocked, balance - held + credit_limit, credit_limit
+		FROM wallets
 		WHERE id = $1
 	`
 
 	queryGetBalance = `
-		SELECT balance
+		SELECT currency, balance, balance - held + credit_limit, credit_limit, GREATEST(-balance, 0)
 		FROM wallets
 		WHERE id = $1
 	`
 
+	// walletColumns selects a wallet w with the limits in effect for it: each
+	// limit set on the wallet overrides the one of its tier.
+	walletColumns = `
+		SELECT w.id, w.owner_ref, w.currency, w.balance, w.status, w.deposits_blocked, w.tier, w.credit_limit, w.metadata, w.created_at, w.updated_at,
+			COALESCE(wl.max_single_amount, tl.max_single_amount),
+			COALESCE(wl.daily_withdrawal_cap, tl.daily_withdrawal_cap),
+			COALESCE(wl.monthly_withdrawal_cap, tl.monthly_withdrawal_cap),
+			COALESCE(wl.max_operations, tl.max_operations),
+			COALESCE(wl.operations_window_seconds, tl.operations_window_seconds),
+			COALESCE(wl.min_balance, tl.min_balance)
+	`
+
+	walletLimitsJoin = `
+		LEFT JOIN wallet_limits wl ON wl.wallet_id = w.id
+		LEFT JOIN wallet_limits tl ON tl.tier = w.tier
+	`
+
+	queryGetWallet = walletColumns + `
+		FROM wallets w` + walletLimitsJoin + `
+		WHERE w.id = $1
+	`
+
+	queryLockWallet = queryGetWallet + `
+		FOR UPDATE OF w
+	`
+
+	queryLockWalletStatus = `
+		SELECT status, balance, held
+		FROM wallets
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	queryUpdateWalletStatus = `
+		WITH w AS (
+			UPDATE wallets
+			SET status = $2, deposits_blocked = $3, updated_at = now()
+			WHERE id = $1
+			RETURNING *
+		)` + walletColumns + `
+		FROM w` + walletLimitsJoin + `
+	`
+
+	// queryUpdateCreditLimit refuses a limit lower than the credit the wallet
+	// already uses, including funds held against the credit line.
+	queryUpdateCreditLimit = `
+		WITH w AS (
+			UPDATE wallets
+			SET credit_limit = $2, updated_at = now()
+			WHERE id = $1 AND balance + $2 >= held
+			RETURNING *
+		)` + walletColumns + `
+		FROM w` + walletLimitsJoin + `
+	`
+
+	queryUpdateWalletTier = `
+		WITH w AS (
+			UPDATE wallets
+			SET tier = $2, updated_at = now()
+			WHERE id = $1
+			RETURNING *
+		)` + walletColumns + `
+		FROM w` + walletLimitsJoin + `
+	`
+
+	queryUpsertWalletLimits = `
+		INSERT INTO wallet_limits (wallet_id, max_single_amount, daily_withdrawal_cap, monthly_withdrawal_cap,
+			max_operations, operations_window_seconds, min_balance)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET max_single_amount = EXCLUDED.max_single_amount,
+			daily_withdrawal_cap = EXCLUDED.daily_withdrawal_cap,
+			monthly_withdrawal_cap = EXCLUDED.monthly_withdrawal_cap,
+			max_operations = EXCLUDED.max_operations,
+			operations_window_seconds = EXCLUDED.operations_window_seconds,
+			min_balance = EXCLUDED.min_balance,
+			updated_at = now()
+	`
+
+	queryUpsertTierLimits = `
+		INSERT INTO wallet_limits (tier, max_single_amount, daily_withdrawal_cap, monthly_withdrawal_cap,
+			max_operations, operations_window_seconds, min_balance)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tier) DO UPDATE
+		SET max_single_amount = EXCLUDED.max_single_amount,
+			daily_withdrawal_cap = EXCLUDED.daily_withdrawal_cap,
+			monthly_withdrawal_cap = EXCLUDED.monthly_withdrawal_cap,
+			max_operations = EXCLUDED.max_operations,
+			operations_window_seconds = EXCLUDED.operations_window_seconds,
+			min_balance = EXCLUDED.min_balance,
+			updated_at = now()
+	`
+
+	// queryGetLimitUsage counts active holds as withdrawals until they are
+	// captured, when the capture transaction takes their place.
+	queryGetLimitUsage = `
+		WITH pending AS (
+			SELECT
+				COALESCE(SUM(amount) FILTER (WHERE created_at >= $4), 0) AS today,
+				COALESCE(SUM(amount) FILTER (WHERE created_at >= $5), 0) AS this_month
+			FROM holds
+			WHERE wallet_id = $1 AND status = 'ACTIVE' AND expires_at > now()
+		)
+		SELECT
+			(SELECT balance - held FROM wallets WHERE id = $1),
+			COALESCE(SUM(amount) FILTER (WHERE operation = ANY($2) AND created_at >= $4), 0) + (SELECT today FROM pending),
+			COALESCE(SUM(amount) FILTER (WHERE operation = ANY($2) AND created_at >= $5), 0) + (SELECT this_month FROM pending),
+			COUNT(*) FILTER (WHERE operation = ANY($3) AND created_at >= $6)
+		FROM transactions
+		WHERE wallet_id = $1 AND created_at >= LEAST($4, $5, $6)
+	`
+
+	queryInsertWalletStatusHistory = `
+		INSERT INTO wallet_status_history (wallet_id, from_status, to_status, deposits_blocked, reason)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	queryGetWalletStatusHistory = `
+		SELECT wallet_id, from_status, to_status, deposits_blocked, reason, created_at
+		FROM wallet_status_history
+		WHERE wallet_id = $1
+		ORDER BY created_at, id
+	`
+
 	queryCreateWallet = `
-		INSERT INTO wallets (id, balance, created_at)
-		VALUES ($1, 0, NOW())
+		INSERT INTO wallets (id, balance, owner_ref, currency, tier, metadata, created_at, updated_at)
+		VALUES ($1, 0, NULLIF($2, ''), $3, $4, $5, NOW(), NOW())
+		RETURNING balance, status, created_at, updated_at
+	`
+
+	queryInsertWalletAccount = `
+		INSERT INTO accounts (type, wallet_id, currency)
+		VALUES ('WALLET', $1, $2)
+	`
+
+	queryEnsureSystemAccounts = `
+		INSERT INTO accounts (type, code, currency)
+		SELECT 'SYSTEM', code, $2
+		FROM unnest($1::text[]) AS code
+		ON CONFLICT (code, currency) WHERE type = 'SYSTEM' DO NOTHING
+	`
+
+	queryInsertJournalEntry = `
+		INSERT INTO journal_entries (kind)
+		VALUES ($1)
+		RETURNING id
+	`
+
+	queryInsertWalletPosting = `
+		INSERT INTO postings (entry_id, account_id, amount, currency)
+		SELECT $1, id, $3, currency
+		FROM accounts
+		WHERE wallet_id = $2
+		RETURNING currency
+	`
+
+	queryInsertSystemPosting = `
+		INSERT INTO postings (entry_id, account_id, amount, currency)
+		SELECT $1, id, $4, currency
+		FROM accounts
+		WHERE type = 'SYSTEM' AND code = $2 AND currency = $3
+	`
+
+	queryReconcileWallet = `
+		SELECT w.balance, COALESCE(SUM(p.amount), 0)
+		FROM wallets w
+		LEFT JOIN accounts a ON a.wallet_id = w.id
+		LEFT JOIN postings p ON p.account_id = a.id
+		WHERE w.id = $1
+		GROUP BY w.id, w.balance
+	`
+
+	queryTrialBalance = `
+		SELECT COALESCE(a.code, 'WALLETS') AS account, p.currency, SUM(p.amount)
+		FROM postings p
+		JOIN accounts a ON a.id = p.account_id
+		GROUP BY 1, 2
+		ORDER BY 2, 1
+	`
+
+	queryReserveFunds = `
+		UPDATE wallets
+		SET held = held + $2, updated_at = now()
+		WHERE id = $1 AND status = 'ACTIVE' AND balance - held + credit_limit >= $2
+	`
+
+	queryReleaseFunds = `
+		UPDATE wallets
+		SET held = held - $2, updated_at = now()
+		WHERE id = $1 AND held >= $2
+	`
+
+	queryInsertHold = `
+		INSERT INTO holds (wallet_id, amount, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, wallet_id, amount, captured_amount, status, expires_at, created_at, updated_at
+	`
+
+	queryGetHold = `
+		SELECT id, wallet_id, amount, captured_amount, status, expires_at, created_at, updated_at
+		FROM holds
+		WHERE id = $1
+	`
+
+	queryLockHold = queryGetHold + ` FOR UPDATE`
+
+	queryUpdateHoldStatus = `
+		UPDATE holds
+		SET status = $2, captured_amount = $3, updated_at = now()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	queryExpireHolds = `
+		WITH expired AS (
+			UPDATE holds
+			SET status = 'EXPIRED', updated_at = now()
+			WHERE status = 'ACTIVE' AND expires_at <= now()
+			RETURNING wallet_id, amount
+		), released AS (
+			SELECT wallet_id, SUM(amount) AS amount
+			FROM expired
+			GROUP BY wallet_id
+		)
+		UPDATE wallets w
+		SET held = w.held - r.amount, updated_at = now()
+		FROM released r
+		WHERE w.id = r.wallet_id
+	`
+
+	queryInsertQuote = `
+		INSERT INTO fx_quotes (from_currency, to_currency, rate, amount, converted_amount, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	queryGetQuote = `
+		SELECT id, from_currency, to_currency, rate, amount, converted_amount, expires_at, used_at, created_at
+		FROM fx_quotes
+		WHERE id = $1
+	`
+
+	queryUseQuote = `
+		UPDATE fx_quotes
+		SET used_at = now()
+		WHERE id = $1 AND used_at IS NULL AND expires_at > now()
+	`
+
+	queryInsertOutboxEvent = `
+		INSERT INTO outbox_events (event_type, aggregate_id, payload)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	// queryClaimOutboxEvents pushes next_attempt_at of the claimed events
+	// forward by $2 seconds, so other relays skip them while they are being
+	// published.
+	queryClaimOutboxEvents = `
+		WITH due AS (
+			SELECT id
+			FROM outbox_events
+			WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= now()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE outbox_events o
+			SET next_attempt_at = now() + make_interval(secs => $2)
+			FROM due
+			WHERE o.id = due.id
+			RETURNING o.id, o.event_type, o.aggregate_id, o.payload, o.attempts, o.created_at
+		)
+		SELECT * FROM claimed ORDER BY id
+	`
+
+	queryMarkOutboxEventPublished = `
+		UPDATE outbox_events
+		SET published_at = now(), last_error = NULL
+		WHERE id = $1
+	`
+
+	queryRecordOutboxFailure = `
+		UPDATE outbox_events
+		SET attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = $3,
+			dead_at = CASE WHEN $4 THEN now() END
+		WHERE id = $1
+	`
+
+	queryGetOutboxLag = `
+		SELECT
+			count(*) FILTER (WHERE dead_at IS NULL),
+			COALESCE(EXTRACT(EPOCH FROM now() - min(created_at) FILTER (WHERE dead_at IS NULL)), 0),
+			count(*) FILTER (WHERE dead_at IS NOT NULL)
+		FROM outbox_events
+		WHERE published_at IS NULL
+	`
+
+	queryInsertWebhookDeliveries = `
+		INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $4
+		FROM webhook_endpoints
+		WHERE active AND (wallet_id IS NULL OR wallet_id = $3) AND $2 = ANY(event_types)
+	`
+
+	queryInsertWebhookEndpoint = `
+		INSERT INTO webhook_endpoints (wallet_id, url, secret, event_types)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, wallet_id, url, event_types, active, created_at, updated_at
+	`
+
+	queryGetWebhookEndpoints = `
+		SELECT id, wallet_id, url, event_types, active, created_at, updated_at
+		FROM webhook_endpoints
+		ORDER BY created_at
+	`
+
+	queryGetWebhookEndpoint = `
+		SELECT id, wallet_id, url, event_types, active, created_at, updated_at
+		FROM webhook_endpoints
+		WHERE id = $1
+	`
+
+	queryDisableWebhookEndpoint = `
+		UPDATE webhook_endpoints
+		SET active = FALSE, updated_at = now()
+		WHERE id = $1
+		RETURNING id, wallet_id, url, event_types, active, created_at, updated_at
+	`
+
+	// queryClaimWebhookDeliveries pushes next_attempt_at of the claimed
+	// deliveries forward by $2 seconds, so other workers skip them while
+	// they are being sent.
+	queryClaimWebhookDeliveries = `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id
+			WHERE d.status = 'PENDING' AND d.next_attempt_at <= now() AND e.active
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + make_interval(secs => $2), updated_at = now()
+		FROM due, webhook_endpoints e
+		WHERE d.id = due.id AND e.id = d.endpoint_id
+		RETURNING ` + webhookDeliveryColumns + `, e.url, e.secret
+	`
+
+	webhookDeliveryColumns = `d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+		d.next_attempt_at, d.last_error, d.delivered_at, d.created_at, d.updated_at`
+
+	queryInsertWebhookAttempt = `
+		INSERT INTO webhook_attempts (delivery_id, succeeded, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	queryUpdateWebhookDelivery = `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1,
+			status = $2,
+			next_attempt_at = $3,
+			last_error = $4,
+			delivered_at = CASE WHEN $2 = 'DELIVERED' THEN now() END,
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	queryGetWebhookDeliveries = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE ($1::uuid IS NULL OR d.endpoint_id = $1) AND ($2 = '' OR d.status = $2)
+		ORDER BY d.created_at DESC
+		LIMIT $3
+	`
+
+	queryGetWebhookDelivery = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.id = $1
+	`
+
+	queryGetWebhookAttempts = `
+		SELECT succeeded, status_code, error, duration_ms, attempted_at
+		FROM webhook_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempted_at
+	`
+
+	queryReplayWebhookDelivery = `
+		UPDATE webhook_deliveries d
+		SET status = 'PENDING', attempts = 0, next_attempt_at = now(), delivered_at = NULL, updated_at = now()
+		WHERE d.id = $1
+		RETURNING ` + webhookDeliveryColumns + `
+	`
+
+	apiKeyColumns = `id, name, prefix, key_hash, scopes, role, rotated_from, expires_at, revoked_at, last_used_at, created_at`
+
+	queryInsertAPIKey = `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, role, rotated_from, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + apiKeyColumns + `
+	`
+
+	queryGetAPIKeys = `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		ORDER BY created_at
+	`
+
+	queryGetAPIKeyByHash = `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE key_hash = $1
+	`
+
+	queryLockAPIKey = `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	queryExpireAPIKey = `
+		UPDATE api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE id = $1
+	`
+
+	queryRevokeAPIKey = `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1
+		RETURNING ` + apiKeyColumns + `
+	`
+
+	queryInsertAPIKeyUsage = `
+		WITH used AS (
+			UPDATE api_keys SET last_used_at = $6 WHERE id = $1
+		)
+		INSERT INTO api_key_usage (key_id, method, route, status, client_ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	queryGetAPIKeyUsage = `
+		SELECT method, route, status, client_ip, created_at
+		FROM api_key_usage
+		WHERE key_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	queryIsWalletOwner = `
+		SELECT EXISTS (
+			SELECT 1 FROM wallet_owners WHERE wallet_id = $1 AND subject = $2
+		)
+	`
+
+	queryGetWalletOwners = `
+		SELECT wallet_id, subject, created_at
+		FROM wallet_owners
+		WHERE wallet_id = $1
+		ORDER BY created_at
+	`
+
+	queryInsertWalletOwner = `
+		INSERT INTO wallet_owners (wallet_id, subject)
+		VALUES ($1, $2)
+		ON CONFLICT (wallet_id, subject) DO UPDATE SET subject = EXCLUDED.subject
+		RETURNING wallet_id, subject, created_at
+	`
+
+	queryDeleteWalletOwner = `
+		DELETE FROM wallet_owners
+		WHERE wallet_id = $1 AND subject = $2
 	`
-)
\ No newline at end of file
+)
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// walletTransitions lists the statuses a wallet may move to from each
+// status. Closed wallets stay closed.
+var walletTransitions = map[string][]string{
+	models.WalletStatusActive: {models.WalletStatusFrozen, models.WalletStatusClosed},
+	models.WalletStatusFrozen: {models.WalletStatusActive, models.WalletStatusClosed},
+}
+
+// ChangeWalletStatus moves the wallet to status and records the change with
+// its reason. Only empty wallets without holds can be closed.
+func (r *pgRepo) ChangeWalletStatus(ctx context.Context, id uuid.UUID, status string, blockDeposits bool, reason string) (models.Wallet, error) {
+	var res models.Wallet
+	err := r.inTx(ctx, "change_wallet_status", func(tx *sql.Tx) error {
+		var current string
+		var balance, held int64
+		err := tx.QueryRowContext(ctx, queryLockWalletStatus, id).Scan(&current, &balance, &held)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(walletTransitions[current], status) {
+			return errors.Wrapf(models.ErrStatusTransition, "%s -> %s", current, status)
+		}
+		if status == models.WalletStatusClosed && (balance != 0 || held != 0) {
+			return errors.Wrapf(models.ErrWalletNotEmpty, "balance %d, held %d", balance, held)
+		}
+
+		_, err = tx.ExecContext(ctx, queryInsertWalletStatusHistory, id, current, status, blockDeposits, reason)
+		if err != nil {
+			return err
+		}
+
+		res, err = scanWallet(tx.QueryRowContext(ctx, queryUpdateWalletStatus, id, status, blockDeposits))
+		return err
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.ChangeWalletStatus")
+		return models.Wallet{}, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) GetWalletStatusHistory(ctx context.Context, id uuid.UUID) ([]models.WalletStatusChange, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetWalletStatusHistory, id)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWalletStatusHistory")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.WalletStatusChange{}
+	for rows.Next() {
+		var c models.WalletStatusChange
+		if err := rows.Scan(&c.WalletID, &c.FromStatus, &c.ToStatus, &c.DepositsBlocked, &c.Reason, &c.CreatedAt); err != nil {
+			err := errors.Wrap(err, "pgRepo.GetWalletStatusHistory")
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWalletStatusHistory")
+		return nil, err
+	}
+	return res, nil
+}
+
+func scanWallet(row *sql.Row) (models.Wallet, error) {
+	var res models.Wallet
+	var metadata []byte
+	limits := &res.Limits
+	err := row.Scan(
+		&res.ID, &res.OwnerRef, &res.Currency, &res.Balance, &res.Status,
+		&res.DepositsBlocked, &res.Tier, &res.CreditLimit, &metadata, &res.CreatedAt, &res.UpdatedAt,
+		&limits.MaxSingleAmount, &limits.DailyWithdrawalCap, &limits.MonthlyWithdrawalCap,
+		&limits.MaxOperations, &limits.OperationsWindowSeconds, &limits.MinBalance,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, models.ErrWalletNotFound
+	}
+	res.Metadata = metadata
+	return res, err
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/SerzhLimon/PaymentService/internal/metrics"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// inTx runs fn in a serializable transaction. Serialization failures and
+// deadlocks abort the whole transaction, so fn is re-run from scratch with
+// jittered exponential backoff, up to retry.MaxAttempts times. fn must not
+// have side effects outside tx. Each retry is added as an event to the span
+// in ctx.
+func (r *pgRepo) inTx(ctx context.Context, name string, fn func(tx *sql.Tx) error) error {
+	maxAttempts := max(r.retry.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err := r.runTx(ctx, fn)
+		if err == nil {
+			if attempt > 1 {
+				logrus.WithFields(logrus.Fields{
+					"tx":       name,
+					"attempts": attempt,
+				}).Info("Transaction succeeded after retry")
+			}
+			return nil
+		}
+
+		if !isRetryable(err) {
+			return err
+		}
+		if attempt >= maxAttempts {
+			logrus.WithFields(logrus.Fields{
+				"tx":       name,
+				"attempts": attempt,
+			}).WithError(err).Warn("Transaction retries exhausted")
+			metrics.TxRetriesExhausted(name)
+			return fmt.Errorf("%w: %w after %d attempts", models.ErrConflict, err, attempt)
+		}
+
+		metrics.TxRetried(name)
+		delay := r.backoff(attempt)
+		trace.SpanFromContext(ctx).AddEvent("tx.retry", trace.WithAttributes(
+			attribute.String("tx.name", name),
+			attribute.Int("tx.attempt", attempt),
+			attribute.String("tx.error", err.Error()),
+			attribute.Int64("tx.retry_delay_ms", delay.Milliseconds()),
+		))
+		logrus.WithFields(logrus.Fields{
+			"tx":      name,
+			"attempt": attempt,
+			"delay":   delay,
+		}).WithError(err).Debug("Retrying transaction")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (r *pgRepo) runTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	txOptions := &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+		ReadOnly:  false,
+	}
+
+	tx, err := r.db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// backoff returns a full-jitter delay for the given attempt: a random
+// duration between zero and BaseDelay*2^(attempt-1), capped at MaxDelay.
+func (r *pgRepo) backoff(attempt int) time.Duration {
+	ceiling := r.retry.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.retry.MaxDelay {
+		ceiling = r.retry.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+func (r *pgRepo) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) (models.WebhookEndpoint, error) {
+	res, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx, queryInsertWebhookEndpoint,
+		endpoint.WalletID, endpoint.URL, endpoint.Secret, pq.Array(endpoint.EventTypes),
+	))
+	if isForeignKeyViolation(err) {
+		return models.WebhookEndpoint{}, errors.Wrap(models.ErrWalletNotFound, "pgRepo.CreateWebhookEndpoint")
+	}
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.CreateWebhookEndpoint")
+		return models.WebhookEndpoint{}, err
+	}
+	res.Secret = endpoint.Secret
+	return res, nil
+}
+
+func (r *pgRepo) GetWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetWebhookEndpoints)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWebhookEndpoints")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.WebhookEndpoint{}
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			err := errors.Wrap(err, "pgRepo.GetWebhookEndpoints")
+			return nil, err
+		}
+		res = append(res, endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWebhookEndpoints")
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *pgRepo) GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (models.WebhookEndpoint, error) {
+	res, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx, queryGetWebhookEndpoint, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errors.Wrap(models.ErrWebhookNotFound, "pgRepo.GetWebhookEndpoint")
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.GetWebhookEndpoint")
+	}
+	return res, nil
+}
+
+// DisableWebhookEndpoint stops new deliveries to the endpoint. Deliveries
+// already queued stay pending and are not sent.
+func (r *pgRepo) DisableWebhookEndpoint(ctx context.Context, id uuid.UUID) (models.WebhookEndpoint, error) {
+	res, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx, queryDisableWebhookEndpoint, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errors.Wrap(models.ErrWebhookNotFound, "pgRepo.DisableWebhookEndpoint")
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.DisableWebhookEndpoint")
+	}
+	return res, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit due deliveries together with
+// the URL and secret of their endpoint, and hides them from other workers
+// for lease.
+func (r *pgRepo) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var res []models.WebhookDelivery
+	err := r.inTx(ctx, "claim_webhook_deliveries", func(tx *sql.Tx) error {
+		res = nil
+		rows, err := tx.QueryContext(ctx, queryClaimWebhookDeliveries, limit, lease.Seconds())
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var d models.WebhookDelivery
+			if err := scanWebhookDelivery(rows, &d, &d.URL, &d.Secret); err != nil {
+				return err
+			}
+			res = append(res, d)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.ClaimWebhookDeliveries")
+		return nil, err
+	}
+	return res, nil
+}
+
+// RecordWebhookAttempt stores attempt and moves its delivery to status,
+// scheduling the next attempt at nextAttemptAt while it stays pending.
+func (r *pgRepo) RecordWebhookAttempt(ctx context.Context, attempt models.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	err := r.inTx(ctx, "record_webhook_attempt", func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, queryInsertWebhookAttempt,
+			attempt.DeliveryID, attempt.Succeeded, attempt.StatusCode, attempt.Error, attempt.DurationMs, attempt.AttemptedAt,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, queryUpdateWebhookDelivery, attempt.DeliveryID, status, nextAttemptAt, attempt.Error)
+		return err
+	})
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.RecordWebhookAttempt")
+		return err
+	}
+	return nil
+}
+
+func (r *pgRepo) GetWebhookDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetWebhookDeliveries, filter.EndpointID, filter.Status, filter.Limit)
+	if err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWebhookDeliveries")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			err := errors.Wrap(err, "pgRepo.GetWebhookDeliveries")
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	if err := rows.Err(); err != nil {
+		err := errors.Wrap(err, "pgRepo.GetWebhookDeliveries")
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetWebhookDelivery returns the delivery with all its attempts.
+func (r *pgRepo) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error) {
+	var res models.WebhookDelivery
+	err := scanWebhookDelivery(r.db.QueryRowContext(ctx, queryGetWebhookDelivery, id), &res)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errors.Wrap(models.ErrDeliveryNotFound, "pgRepo.GetWebhookDelivery")
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.GetWebhookDelivery")
+	}
+
+	rows, err := r.db.QueryContext(ctx, queryGetWebhookAttempts, id)
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.GetWebhookDelivery")
+	}
+	defer rows.Close()
+
+	res.History = []models.WebhookAttempt{}
+	for rows.Next() {
+		attempt := models.WebhookAttempt{DeliveryID: res.ID}
+		err := rows.Scan(&attempt.Succeeded, &attempt.StatusCode, &attempt.Error, &attempt.DurationMs, &attempt.AttemptedAt)
+		if err != nil {
+			return res, errors.Wrap(err, "pgRepo.GetWebhookDelivery")
+		}
+		res.History = append(res.History, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return res, errors.Wrap(err, "pgRepo.GetWebhookDelivery")
+	}
+	return res, nil
+}
+
+// ReplayWebhookDelivery queues a delivery again with a fresh attempt budget.
+// Its attempt history is kept.
+func (r *pgRepo) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error) {
+	var res models.WebhookDelivery
+	err := scanWebhookDelivery(r.db.QueryRowContext(ctx, queryReplayWebhookDelivery, id), &res)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errors.Wrap(models.ErrDeliveryNotFound, "pgRepo.ReplayWebhookDelivery")
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "pgRepo.ReplayWebhookDelivery")
+	}
+	return res, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhookEndpoint(row scanner) (models.WebhookEndpoint, error) {
+	var res models.WebhookEndpoint
+	err := row.Scan(&res.ID, &res.WalletID, &res.URL, pq.Array(&res.EventTypes), &res.Active, &res.CreatedAt, &res.UpdatedAt)
+	return res, err
+}
+
+func scanWebhookDelivery(row scanner, d *models.WebhookDelivery, extra ...any) error {
+	var payload []byte
+	dest := []any{
+		&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastError, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	d.Payload = payload
+	return nil
+}
@@ -0,0 +1,290 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/outbox"
+	"github.com/SerzhLimon/PaymentService/internal/usecase"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
+)
+
+// Usecase wraps every call to a UseCase in a span, so a trace shows how long
+// each step of a request took and which one failed.
+type Usecase struct {
+	next usecase.UseCase
+}
+
+func NewUsecase(next usecase.UseCase) usecase.UseCase {
+	return &Usecase{next: next}
+}
+
+func (t *Usecase) WalletTransaction(ctx context.Context, data models.WalletTransaction) (_ models.Transaction, err error) {
+	ctx, span := start(ctx, "WalletTransaction")
+	defer func() { end(span, err) }()
+	return t.next.WalletTransaction(ctx, data)
+}
+
+func (t *Usecase) Transfer(ctx context.Context, data models.TransferRequest) (_ models.Transfer, err error) {
+	ctx, span := start(ctx, "Transfer")
+	defer func() { end(span, err) }()
+	return t.next.Transfer(ctx, data)
+}
+
+func (t *Usecase) GetBalance(ctx context.Context, id string) (_ models.GetBalanceResponse, err error) {
+	ctx, span := start(ctx, "GetBalance")
+	defer func() { end(span, err) }()
+	return t.next.GetBalance(ctx, id)
+}
+
+func (t *Usecase) GetTransactions(ctx context.Context, data models.GetTransactionsRequest) (_ models.TransactionList, err error) {
+	ctx, span := start(ctx, "GetTransactions")
+	defer func() { end(span, err) }()
+	return t.next.GetTransactions(ctx, data)
+}
+
+func (t *Usecase) CreateWallet(ctx context.Context, data models.CreateWalletRequest) (_ models.Wallet, err error) {
+	ctx, span := start(ctx, "CreateWallet")
+	defer func() { end(span, err) }()
+	return t.next.CreateWallet(ctx, data)
+}
+
+func (t *Usecase) ReconcileWallet(ctx context.Context, id string) (_ models.Reconciliation, err error) {
+	ctx, span := start(ctx, "ReconcileWallet")
+	defer func() { end(span, err) }()
+	return t.next.ReconcileWallet(ctx, id)
+}
+
+func (t *Usecase) GetTrialBalance(ctx context.Context) (_ models.TrialBalance, err error) {
+	ctx, span := start(ctx, "GetTrialBalance")
+	defer func() { end(span, err) }()
+	return t.next.GetTrialBalance(ctx)
+}
+
+func (t *Usecase) CreateQuote(ctx context.Context, data models.CreateQuoteRequest) (_ models.Quote, err error) {
+	ctx, span := start(ctx, "CreateQuote")
+	defer func() { end(span, err) }()
+	return t.next.CreateQuote(ctx, data)
+}
+
+func (t *Usecase) GetQuote(ctx context.Context, id string) (_ models.Quote, err error) {
+	ctx, span := start(ctx, "GetQuote")
+	defer func() { end(span, err) }()
+	return t.next.GetQuote(ctx, id)
+}
+
+func (t *Usecase) ReverseTransaction(ctx context.Context, data models.ReverseTransactionRequest) (_ models.Reversal, err error) {
+	ctx, span := start(ctx, "ReverseTransaction")
+	defer func() { end(span, err) }()
+	return t.next.ReverseTransaction(ctx, data)
+}
+
+func (t *Usecase) FreezeWallet(ctx context.Context, data models.WalletStatusRequest) (_ models.Wallet, err error) {
+	ctx, span := start(ctx, "FreezeWallet")
+	defer func() { end(span, err) }()
+	return t.next.FreezeWallet(ctx, data)
+}
+
+func (t *Usecase) UnfreezeWallet(ctx context.Context, data models.WalletStatusRequest) (_ models.Wallet, err error) {
+	ctx, span := start(ctx, "UnfreezeWallet")
+	defer func() { end(span, err) }()
+	return t.next.UnfreezeWallet(ctx, data)
+}
+
+func (t *Usecase) CloseWallet(ctx context.Context, data models.WalletStatusRequest) (_ models.Wallet, err error) {
+	ctx, span := start(ctx, "CloseWallet")
+	defer func() { end(span, err) }()
+	return t.next.CloseWallet(ctx, data)
+}
+
+func (t *Usecase) GetWalletStatusHistory(ctx context.Context, id string) (_ []models.WalletStatusChange, err error) {
+	ctx, span := start(ctx, "GetWalletStatusHistory")
+	defer func() { end(span, err) }()
+	return t.next.GetWalletStatusHistory(ctx, id)
+}
+
+func (t *Usecase) GetWalletLimits(ctx context.Context, id string) (_ models.Limits, err error) {
+	ctx, span := start(ctx, "GetWalletLimits")
+	defer func() { end(span, err) }()
+	return t.next.GetWalletLimits(ctx, id)
+}
+
+func (t *Usecase) SetWalletLimits(ctx context.Context, data models.SetLimitsRequest) (_ models.Limits, err error) {
+	ctx, span := start(ctx, "SetWalletLimits")
+	defer func() { end(span, err) }()
+	return t.next.SetWalletLimits(ctx, data)
+}
+
+func (t *Usecase) SetTierLimits(ctx context.Context, data models.SetLimitsRequest) (_ models.Limits, err error) {
+	ctx, span := start(ctx, "SetTierLimits")
+	defer func() { end(span, err) }()
+	return t.next.SetTierLimits(ctx, data)
+}
+
+func (t *Usecase) SetWalletTier(ctx context.Context, data models.SetTierRequest) (_ models.Wallet, err error) {
+	ctx, span := start(ctx, "SetWalletTier")
+	defer func() { end(span, err) }()
+	return t.next.SetWalletTier(ctx, data)
+}
+
+func (t *Usecase) SetCreditLimit(ctx context.Context, data models.SetCreditLimitRequest) (_ models.Wallet, err error) {
+	ctx, span := start(ctx, "SetCreditLimit")
+	defer func() { end(span, err) }()
+	return t.next.SetCreditLimit(ctx, data)
+}
+
+func (t *Usecase) GetWalletOwners(ctx context.Context, id string) (_ []models.WalletOwner, err error) {
+	ctx, span := start(ctx, "GetWalletOwners")
+	defer func() { end(span, err) }()
+	return t.next.GetWalletOwners(ctx, id)
+}
+
+func (t *Usecase) AddWalletOwner(ctx context.Context, data models.WalletOwnerRequest) (_ models.WalletOwner, err error) {
+	ctx, span := start(ctx, "AddWalletOwner")
+	defer func() { end(span, err) }()
+	return t.next.AddWalletOwner(ctx, data)
+}
+
+func (t *Usecase) RemoveWalletOwner(ctx context.Context, data models.WalletOwnerRequest) (err error) {
+	ctx, span := start(ctx, "RemoveWalletOwner")
+	defer func() { end(span, err) }()
+	return t.next.RemoveWalletOwner(ctx, data)
+}
+
+func (t *Usecase) CreateHold(ctx context.Context, data models.CreateHoldRequest) (_ models.Hold, err error) {
+	ctx, span := start(ctx, "CreateHold")
+	defer func() { end(span, err) }()
+	return t.next.CreateHold(ctx, data)
+}
+
+func (t *Usecase) GetHold(ctx context.Context, id string) (_ models.Hold, err error) {
+	ctx, span := start(ctx, "GetHold")
+	defer func() { end(span, err) }()
+	return t.next.GetHold(ctx, id)
+}
+
+func (t *Usecase) CaptureHold(ctx context.Context, data models.CaptureHoldRequest) (_ models.CaptureHoldResponse, err error) {
+	ctx, span := start(ctx, "CaptureHold")
+	defer func() { end(span, err) }()
+	return t.next.CaptureHold(ctx, data)
+}
+
+func (t *Usecase) VoidHold(ctx context.Context, id string) (_ models.Hold, err error) {
+	ctx, span := start(ctx, "VoidHold")
+	defer func() { end(span, err) }()
+	return t.next.VoidHold(ctx, id)
+}
+
+func (t *Usecase) ExpireHolds(ctx context.Context) (_ int64, err error) {
+	ctx, span := start(ctx, "ExpireHolds")
+	defer func() { end(span, err) }()
+	return t.next.ExpireHolds(ctx)
+}
+
+func (t *Usecase) RelayOutbox(ctx context.Context, publisher outbox.Publisher, policy webhook.RetryPolicy, limit int) (_ int, err error) {
+	ctx, span := start(ctx, "RelayOutbox")
+	defer func() { end(span, err) }()
+	return t.next.RelayOutbox(ctx, publisher, policy, limit)
+}
+
+func (t *Usecase) GetOutboxLag(ctx context.Context) (_ models.OutboxLag, err error) {
+	ctx, span := start(ctx, "GetOutboxLag")
+	defer func() { end(span, err) }()
+	return t.next.GetOutboxLag(ctx)
+}
+
+func (t *Usecase) CreateWebhook(ctx context.Context, data models.CreateWebhookRequest) (_ models.WebhookEndpoint, err error) {
+	ctx, span := start(ctx, "CreateWebhook")
+	defer func() { end(span, err) }()
+	return t.next.CreateWebhook(ctx, data)
+}
+
+func (t *Usecase) GetWebhooks(ctx context.Context) (_ []models.WebhookEndpoint, err error) {
+	ctx, span := start(ctx, "GetWebhooks")
+	defer func() { end(span, err) }()
+	return t.next.GetWebhooks(ctx)
+}
+
+func (t *Usecase) GetWebhook(ctx context.Context, id string) (_ models.WebhookEndpoint, err error) {
+	ctx, span := start(ctx, "GetWebhook")
+	defer func() { end(span, err) }()
+	return t.next.GetWebhook(ctx, id)
+}
+
+func (t *Usecase) DeleteWebhook(ctx context.Context, id string) (_ models.WebhookEndpoint, err error) {
+	ctx, span := start(ctx, "DeleteWebhook")
+	defer func() { end(span, err) }()
+	return t.next.DeleteWebhook(ctx, id)
+}
+
+func (t *Usecase) GetWebhookDeliveries(ctx context.Context, data models.GetWebhookDeliveriesRequest) (_ []models.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "GetWebhookDeliveries")
+	defer func() { end(span, err) }()
+	return t.next.GetWebhookDeliveries(ctx, data)
+}
+
+func (t *Usecase) GetWebhookDelivery(ctx context.Context, id string) (_ models.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "GetWebhookDelivery")
+	defer func() { end(span, err) }()
+	return t.next.GetWebhookDelivery(ctx, id)
+}
+
+func (t *Usecase) ReplayWebhookDelivery(ctx context.Context, id string) (_ models.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "ReplayWebhookDelivery")
+	defer func() { end(span, err) }()
+	return t.next.ReplayWebhookDelivery(ctx, id)
+}
+
+func (t *Usecase) DeliverWebhooks(ctx context.Context, sender webhook.Sender, policy webhook.RetryPolicy, limit int) (_ int, err error) {
+	ctx, span := start(ctx, "DeliverWebhooks")
+	defer func() { end(span, err) }()
+	return t.next.DeliverWebhooks(ctx, sender, policy, limit)
+}
+
+func (t *Usecase) CreateAPIKey(ctx context.Context, data models.CreateAPIKeyRequest) (_ models.APIKey, err error) {
+	ctx, span := start(ctx, "CreateAPIKey")
+	defer func() { end(span, err) }()
+	return t.next.CreateAPIKey(ctx, data)
+}
+
+func (t *Usecase) GetAPIKeys(ctx context.Context) (_ []models.APIKey, err error) {
+	ctx, span := start(ctx, "GetAPIKeys")
+	defer func() { end(span, err) }()
+	return t.next.GetAPIKeys(ctx)
+}
+
+func (t *Usecase) RotateAPIKey(ctx context.Context, data models.RotateAPIKeyRequest) (_ models.APIKey, err error) {
+	ctx, span := start(ctx, "RotateAPIKey")
+	defer func() { end(span, err) }()
+	return t.next.RotateAPIKey(ctx, data)
+}
+
+func (t *Usecase) RevokeAPIKey(ctx context.Context, id string) (_ models.APIKey, err error) {
+	ctx, span := start(ctx, "RevokeAPIKey")
+	defer func() { end(span, err) }()
+	return t.next.RevokeAPIKey(ctx, id)
+}
+
+func (t *Usecase) GetAPIKeyUsage(ctx context.Context, data models.GetAPIKeyUsageRequest) (_ []models.APIKeyUsage, err error) {
+	ctx, span := start(ctx, "GetAPIKeyUsage")
+	defer func() { end(span, err) }()
+	return t.next.GetAPIKeyUsage(ctx, data)
+}
+
+func (t *Usecase) AuthenticateAPIKey(ctx context.Context, key string) (_ models.APIKey, err error) {
+	ctx, span := start(ctx, "AuthenticateAPIKey")
+	defer func() { end(span, err) }()
+	return t.next.AuthenticateAPIKey(ctx, key)
+}
+
+func (t *Usecase) RecordAPIKeyUsage(ctx context.Context, data models.APIKeyUsage) (err error) {
+	ctx, span := start(ctx, "RecordAPIKeyUsage")
+	defer func() { end(span, err) }()
+	return t.next.RecordAPIKeyUsage(ctx, data)
+}
+
+func (t *Usecase) BootstrapAPIKey(ctx context.Context, key string) (err error) {
+	ctx, span := start(ctx, "BootstrapAPIKey")
+	defer func() { end(span, err) }()
+	return t.next.BootstrapAPIKey(ctx, key)
+}
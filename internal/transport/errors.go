@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// errorStatuses maps domain errors to HTTP statuses. Errors that match none
+// of them are reported as internal.
+var errorStatuses = []struct {
+	err    *models.Error
+	status int
+}{
+	{models.ErrTimeout, http.StatusGatewayTimeout},
+	{models.ErrInvalidRequest, http.StatusBadRequest},
+	{models.ErrUnauthorized, http.StatusUnauthorized},
+	{models.ErrForbidden, http.StatusForbidden},
+	{models.ErrAPIKeyNotFound, http.StatusNotFound},
+	{models.ErrAPIKeyRevoked, http.StatusConflict},
+	{models.ErrInvalidAmount, http.StatusBadRequest},
+	{models.ErrUnknownOperation, http.StatusBadRequest},
+	{models.ErrWalletNotFound, http.StatusNotFound},
+	{models.ErrOwnerNotFound, http.StatusNotFound},
+	{models.ErrTransactionNotFound, http.StatusNotFound},
+	{models.ErrNotReversible, http.StatusConflict},
+	{models.ErrReversalExceeded, http.StatusUnprocessableEntity},
+	{models.ErrHoldNotFound, http.StatusNotFound},
+	{models.ErrWebhookNotFound, http.StatusNotFound},
+	{models.ErrDeliveryNotFound, http.StatusNotFound},
+	{models.ErrHoldNotActive, http.StatusConflict},
+	{models.ErrQuoteNotFound, http.StatusNotFound},
+	{models.ErrQuoteExpired, http.StatusConflict},
+	{models.ErrWalletFrozen, http.StatusConflict},
+	{models.ErrWalletClosed, http.StatusConflict},
+	{models.ErrWalletNotEmpty, http.StatusConflict},
+	{models.ErrStatusTransition, http.StatusConflict},
+	{models.ErrConflict, http.StatusConflict},
+	{models.ErrWalletExists, http.StatusConflict},
+	{models.ErrIdempotencyConflict, http.StatusConflict},
+	{models.ErrInsufficientFunds, http.StatusUnprocessableEntity},
+	{models.ErrLimitExceeded, http.StatusUnprocessableEntity},
+	{models.ErrCreditLimitExceeded, http.StatusUnprocessableEntity},
+	{models.ErrCurrencyMismatch, http.StatusUnprocessableEntity},
+	{models.ErrRateUnavailable, http.StatusUnprocessableEntity},
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"error"`
+	Details gin.H  `json:"details,omitempty"`
+}
+
+// abortWithError logs err and writes the matching error response. Errors not
+// known to the domain model are reported with internalMessage and status 500.
+func abortWithError(c *gin.Context, err error, internalMessage string) {
+	logrus.Error(err)
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		err = errors.Join(models.ErrTimeout, err)
+	}
+
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.err) {
+			c.AbortWithStatusJSON(e.status, errorResponse{
+				Code:    e.err.Code,
+				Message: e.err.Message,
+				Details: errorDetails(err),
+			})
+			return
+		}
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse{
+		Code:    models.ErrInternal.Code,
+		Message: internalMessage,
+	})
+}
+
+// abortWithBadRequest reports a request that could not be parsed at all.
+func abortWithBadRequest(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse{
+		Code:    models.ErrInvalidRequest.Code,
+		Message: message,
+	})
+}
+
+func errorDetails(err error) gin.H {
+	var insufficient *models.InsufficientFundsError
+	if errors.As(err, &insufficient) {
+		return gin.H{"available_balance": insufficient.Available}
+	}
+	var credit *models.CreditLimitExceededError
+	if errors.As(err, &credit) {
+		return gin.H{"available_balance": credit.Available, "credit_limit": credit.CreditLimit}
+	}
+	var limit *models.LimitExceededError
+	if errors.As(err, &limit) {
+		return gin.H{"rule": limit.Rule, "limit": limit.Limit}
+	}
+	return nil
+}
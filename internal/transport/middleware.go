@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/auth"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	uc "github.com/SerzhLimon/PaymentService/internal/usecase"
+)
+
+// RequestTimeout bounds the request context, so database work started by a
+// handler is cancelled once the deadline passes or the client goes away.
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+const (
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+
+	apiKeyHeader     = "X-API-Key"
+	apiKeyContextKey = "api_key"
+
+	usageRecordTimeout = 2 * time.Second
+)
+
+// Authenticate admits requests to the named route made with either an API key
+// granting the route's scope or, on end-user routes, a valid bearer JWT. The
+// token's subject is put in the request context, where the usecase checks it
+// against the owners of the wallet the request is for.
+func Authenticate(s *Server, route string) gin.HandlerFunc {
+	apiKey := RequireAPIKey(s.Usecase, routeScope(route))
+	endUsers := endUserRoutes[route]
+
+	return func(c *gin.Context) {
+		header := c.GetHeader(authorizationHeader)
+		if !strings.HasPrefix(header, bearerPrefix) {
+			apiKey(c)
+			return
+		}
+
+		if s.Tokens == nil {
+			abortWithError(c, fmt.Errorf("%w: bearer tokens are not accepted", models.ErrUnauthorized), "")
+			return
+		}
+		subject, err := s.Tokens.Verify(c.Request.Context(), strings.TrimPrefix(header, bearerPrefix))
+		if err != nil {
+			abortWithError(c, fmt.Errorf("%w: %v", models.ErrUnauthorized, err), "failed to authenticate request")
+			return
+		}
+		if !endUsers {
+			abortWithError(c, fmt.Errorf("%w: route %s needs an API key", models.ErrForbidden, route), "")
+			return
+		}
+
+		ctx := auth.WithSubject(c.Request.Context(), subject)
+		c.Request = c.Request.WithContext(auth.WithRole(ctx, models.RoleCustomer))
+		c.Next()
+	}
+}
+
+// RequireAPIKey authenticates the request by its X-API-Key header and checks
+// that the key grants scope. Every request made with a valid key, allowed or
+// not, is added to the key's audit log.
+func RequireAPIKey(usecase uc.UseCase, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := usecase.AuthenticateAPIKey(c.Request.Context(), c.GetHeader(apiKeyHeader))
+		if err != nil {
+			abortWithError(c, err, "failed to authenticate request")
+			return
+		}
+		defer recordAPIKeyUsage(c, usecase, key)
+
+		if !key.HasScope(scope) {
+			abortWithError(c, fmt.Errorf("%w: key %s needs scope %s", models.ErrForbidden, key.ID, scope), "")
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Request = c.Request.WithContext(auth.WithRole(c.Request.Context(), key.Role))
+		c.Next()
+	}
+}
+
+func recordAPIKeyUsage(c *gin.Context, usecase uc.UseCase, key models.APIKey) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), usageRecordTimeout)
+	defer cancel()
+
+	err := usecase.RecordAPIKeyUsage(ctx, models.APIKeyUsage{
+		KeyID:     key.ID,
+		Method:    c.Request.Method,
+		Route:     c.FullPath(),
+		Status:    c.Writer.Status(),
+		ClientIP:  c.ClientIP(),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("api_key", key.ID).Error("Failed to record API key usage")
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/SerzhLimon/PaymentService/config"
+	"github.com/SerzhLimon/PaymentService/internal/metrics"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/tracing"
 )
 
 type Route struct {
@@ -13,8 +18,10 @@ type Route struct {
 	HandlerFunc gin.HandlerFunc
 }
 
-func NewRouter(handleFunctions ApiHandleFunctions) *gin.Engine {
-	return NewRouterWithGinEngine(gin.Default(), handleFunctions)
+func NewRouter(handleFunctions ApiHandleFunctions, cfg config.HTTPConfig) *gin.Engine {
+	router := gin.Default()
+	router.Use(RequestTimeout(cfg.RequestTimeout))
+	return NewRouterWithGinEngine(router, handleFunctions)
 }
 
 func NewRouterWithGinEngine(router *gin.Engine, handleFunctions ApiHandleFunctions) *gin.Engine {
@@ -22,18 +29,25 @@ func NewRouterWithGinEngine(router *gin.Engine, handleFunctions ApiHandleFunctio
 		if route.HandlerFunc == nil {
 			route.HandlerFunc = DefaultHandleFunc
 		}
+		handlers := []gin.HandlerFunc{
+			tracing.Middleware(route.Name, route.Pattern),
+			metrics.Instrument(route.Name),
+			Authenticate(&handleFunctions.Server, route.Name),
+			route.HandlerFunc,
+		}
 		switch route.Method {
 		case http.MethodGet:
-			router.GET(route.Pattern, route.HandlerFunc)
+			router.GET(route.Pattern, handlers...)
 		case http.MethodPost:
-			router.POST(route.Pattern, route.HandlerFunc)
+			router.POST(route.Pattern, handlers...)
+		case http.MethodPut:
+			router.PUT(route.Pattern, handlers...)
 		case http.MethodPatch:
-			router.PATCH(route.Pattern, route.HandlerFunc)
+			router.PATCH(route.Pattern, handlers...)
 		case http.MethodDelete:
-			router.DELETE(route.Pattern, route.HandlerFunc)
+			router.DELETE(route.Pattern, handlers...)
 		}
 	}
-
 	return router
 }
 
@@ -41,6 +55,44 @@ func DefaultHandleFunc(c *gin.Context) {
 	c.String(http.StatusNotImplemented, "501 not implemented")
 }
 
+// routeScopes lists the scope each route requires. Routes not listed here
+// require models.ScopeAdmin.
+var routeScopes = map[string]string{
+	"GetBalance":      models.ScopeBalanceRead,
+	"GetTransactions": models.ScopeBalanceRead,
+	"GetWalletLimits": models.ScopeBalanceRead,
+	"GetHold":         models.ScopeBalanceRead,
+	"GetQuote":        models.ScopeBalanceRead,
+
+	"Wallet":       models.ScopeTransact,
+	"Transfer":     models.ScopeTransact,
+	"CreateWallet": models.ScopeTransact,
+	"CreateHold":   models.ScopeTransact,
+	"CaptureHold":  models.ScopeTransact,
+	"VoidHold":     models.ScopeTransact,
+	"CreateQuote":  models.ScopeTransact,
+
+	"CreateWebhook": models.ScopeTransact,
+	"GetWebhooks":   models.ScopeTransact,
+}
+
+// endUserRoutes lists the routes open to end users with a bearer JWT. Each of
+// them only serves wallets owned by the token's subject.
+var endUserRoutes = map[string]bool{
+	"Wallet":          true,
+	"Transfer":        true,
+	"GetBalance":      true,
+	"GetTransactions": true,
+	"GetWalletLimits": true,
+}
+
+func routeScope(name string) string {
+	if scope, ok := routeScopes[name]; ok {
+		return scope
+	}
+	return models.ScopeAdmin
+}
+
 type ApiHandleFunctions struct {
 	Server Server
 }
@@ -53,6 +105,18 @@ func getRoutes(handleFunctions ApiHandleFunctions) []Route {
 			"/api/v1/wallet",
 			handleFunctions.Server.WalletTransaction,
 		},
+		{
+			"Transfer",
+			http.MethodPost,
+			"/api/v1/transfers",
+			handleFunctions.Server.Transfer,
+		},
+		{
+			"ReverseTransaction",
+			http.MethodPost,
+			"/api/v1/transactions/:id/reverse",
+			handleFunctions.Server.ReverseTransaction,
+		},
 		{
 			"GetBalance",
 			http.MethodGet,
@@ -60,10 +124,208 @@ func getRoutes(handleFunctions ApiHandleFunctions) []Route {
 			handleFunctions.Server.GetBalance,
 		},
 		{
-			"CreateWallet",
+			"GetTransactions",
+			http.MethodGet,
+			"/api/v1/wallets/:id/transactions",
+			handleFunctions.Server.GetTransactions,
+		},
+		{
+			"ReconcileWallet",
 			http.MethodGet,
-			"/api/v1/create",
+			"/api/v1/wallets/:id/reconciliation",
+			handleFunctions.Server.ReconcileWallet,
+		},
+		{
+			"GetTrialBalance",
+			http.MethodGet,
+			"/api/v1/ledger/trial-balance",
+			handleFunctions.Server.GetTrialBalance,
+		},
+		{
+			"CreateWallet",
+			http.MethodPost,
+			"/api/v1/wallets",
 			handleFunctions.Server.CreateWallet,
 		},
+		{
+			"CreateHold",
+			http.MethodPost,
+			"/api/v1/wallets/:id/holds",
+			handleFunctions.Server.CreateHold,
+		},
+		{
+			"GetHold",
+			http.MethodGet,
+			"/api/v1/holds/:id",
+			handleFunctions.Server.GetHold,
+		},
+		{
+			"CaptureHold",
+			http.MethodPost,
+			"/api/v1/holds/:id/capture",
+			handleFunctions.Server.CaptureHold,
+		},
+		{
+			"VoidHold",
+			http.MethodPost,
+			"/api/v1/holds/:id/void",
+			handleFunctions.Server.VoidHold,
+		},
+		{
+			"CreateQuote",
+			http.MethodPost,
+			"/api/v1/fx/quotes",
+			handleFunctions.Server.CreateQuote,
+		},
+		{
+			"GetQuote",
+			http.MethodGet,
+			"/api/v1/fx/quotes/:id",
+			handleFunctions.Server.GetQuote,
+		},
+		{
+			"FreezeWallet",
+			http.MethodPost,
+			"/api/v1/admin/wallets/:id/freeze",
+			handleFunctions.Server.FreezeWallet,
+		},
+		{
+			"UnfreezeWallet",
+			http.MethodPost,
+			"/api/v1/admin/wallets/:id/unfreeze",
+			handleFunctions.Server.UnfreezeWallet,
+		},
+		{
+			"CloseWallet",
+			http.MethodPost,
+			"/api/v1/admin/wallets/:id/close",
+			handleFunctions.Server.CloseWallet,
+		},
+		{
+			"GetWalletStatusHistory",
+			http.MethodGet,
+			"/api/v1/admin/wallets/:id/status-history",
+			handleFunctions.Server.GetWalletStatusHistory,
+		},
+		{
+			"GetWalletLimits",
+			http.MethodGet,
+			"/api/v1/wallets/:id/limits",
+			handleFunctions.Server.GetWalletLimits,
+		},
+		{
+			"SetWalletLimits",
+			http.MethodPut,
+			"/api/v1/admin/wallets/:id/limits",
+			handleFunctions.Server.SetWalletLimits,
+		},
+		{
+			"SetWalletTier",
+			http.MethodPut,
+			"/api/v1/admin/wallets/:id/tier",
+			handleFunctions.Server.SetWalletTier,
+		},
+		{
+			"SetCreditLimit",
+			http.MethodPut,
+			"/api/v1/admin/wallets/:id/credit-limit",
+			handleFunctions.Server.SetCreditLimit,
+		},
+		{
+			"GetWalletOwners",
+			http.MethodGet,
+			"/api/v1/admin/wallets/:id/owners",
+			handleFunctions.Server.GetWalletOwners,
+		},
+		{
+			"AddWalletOwner",
+			http.MethodPut,
+			"/api/v1/admin/wallets/:id/owners/:subject",
+			handleFunctions.Server.AddWalletOwner,
+		},
+		{
+			"RemoveWalletOwner",
+			http.MethodDelete,
+			"/api/v1/admin/wallets/:id/owners/:subject",
+			handleFunctions.Server.RemoveWalletOwner,
+		},
+		{
+			"SetTierLimits",
+			http.MethodPut,
+			"/api/v1/admin/tiers/:tier/limits",
+			handleFunctions.Server.SetTierLimits,
+		},
+		{
+			"CreateWebhook",
+			http.MethodPost,
+			"/api/v1/webhooks",
+			handleFunctions.Server.CreateWebhook,
+		},
+		{
+			"GetWebhooks",
+			http.MethodGet,
+			"/api/v1/webhooks",
+			handleFunctions.Server.GetWebhooks,
+		},
+		{
+			"GetWebhook",
+			http.MethodGet,
+			"/api/v1/webhooks/:id",
+			handleFunctions.Server.GetWebhook,
+		},
+		{
+			"DeleteWebhook",
+			http.MethodDelete,
+			"/api/v1/webhooks/:id",
+			handleFunctions.Server.DeleteWebhook,
+		},
+		{
+			"GetWebhookDeliveries",
+			http.MethodGet,
+			"/api/v1/webhook-deliveries",
+			handleFunctions.Server.GetWebhookDeliveries,
+		},
+		{
+			"GetWebhookDelivery",
+			http.MethodGet,
+			"/api/v1/webhook-deliveries/:id",
+			handleFunctions.Server.GetWebhookDelivery,
+		},
+		{
+			"ReplayWebhookDelivery",
+			http.MethodPost,
+			"/api/v1/webhook-deliveries/:id/replay",
+			handleFunctions.Server.ReplayWebhookDelivery,
+		},
+		{
+			"CreateAPIKey",
+			http.MethodPost,
+			"/api/v1/admin/api-keys",
+			handleFunctions.Server.CreateAPIKey,
+		},
+		{
+			"GetAPIKeys",
+			http.MethodGet,
+			"/api/v1/admin/api-keys",
+			handleFunctions.Server.GetAPIKeys,
+		},
+		{
+			"RotateAPIKey",
+			http.MethodPost,
+			"/api/v1/admin/api-keys/:id/rotate",
+			handleFunctions.Server.RotateAPIKey,
+		},
+		{
+			"RevokeAPIKey",
+			http.MethodPost,
+			"/api/v1/admin/api-keys/:id/revoke",
+			handleFunctions.Server.RevokeAPIKey,
+		},
+		{
+			"GetAPIKeyUsage",
+			http.MethodGet,
+			"/api/v1/admin/api-keys/:id/usage",
+			handleFunctions.Server.GetAPIKeyUsage,
+		},
 	}
 }
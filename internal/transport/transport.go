@@ -1,29 +1,67 @@
 package transport
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"github.com/SerzhLimon/PaymentService/config"
+	"github.com/SerzhLimon/PaymentService/internal/auth"
+	"github.com/SerzhLimon/PaymentService/internal/fx"
+	"github.com/SerzhLimon/PaymentService/internal/metrics"
 	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/policy"
 	"github.com/SerzhLimon/PaymentService/internal/repository"
+	"github.com/SerzhLimon/PaymentService/internal/tracing"
 	uc "github.com/SerzhLimon/PaymentService/internal/usecase"
 )
 
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 type Server struct {
 	Usecase uc.UseCase
+	// Tokens verifies end-user JWTs. Bearer tokens are rejected when it is nil.
+	Tokens *auth.Verifier
 }
 
-func NewServer(database *sql.DB) *Server {
-	pgClient := repository.NewPGRepository(database)
-	uc := uc.NewUsecase(pgClient)
+func NewServer(database *sql.DB, cfg config.Config) *Server {
+	pgClient := repository.NewPGRepository(database, cfg.TxRetry)
+
+	rates, err := fx.NewFileProvider(cfg.FX.RatesFile)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load FX rates, cross-currency transfers are disabled")
+		rates, _ = fx.NewStaticProvider(nil)
+	}
+	uc := uc.NewUsecase(pgClient, rates, cfg.FX.QuoteTTL)
 
 	return &Server{
-		Usecase: uc,
+		Usecase: tracing.NewUsecase(policy.NewUsecase(uc, policy.DefaultRules)),
+		Tokens:  newTokenVerifier(cfg.JWT),
+	}
+}
+
+func newTokenVerifier(cfg config.JWTConfig) *auth.Verifier {
+	if cfg.JWKS == "" {
+		return nil
+	}
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		logrus.Warn("JWT_ISSUER and JWT_AUDIENCE must both be set, bearer tokens are rejected")
+		return nil
+	}
+	keys, err := auth.NewKeySet(cfg.JWKS, cfg.JWKSRefresh)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load JWKS, bearer tokens are rejected")
+		return nil
 	}
+	return auth.NewVerifier(keys, cfg.Issuer, cfg.Audience)
 }
 
 func (s *Server) WalletTransaction(c *gin.Context) {
@@ -31,19 +69,76 @@ func (s *Server) WalletTransaction(c *gin.Context) {
 	var request models.WalletTransaction
 	if err := c.ShouldBindJSON(&request); err != nil {
 		logrus.WithError(err).Error("error binding JSON")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON format"})
+		abortWithBadRequest(c, "invalid JSON format")
 		return
 	}
 
+	request.IdempotencyKey = c.GetHeader(idempotencyKeyHeader)
+
 	logrus.SetLevel(logrus.DebugLevel)
 	logrus.Debugf("Parsed request: %s %s %d", request.WalletID, request.Operation, request.Amount)
 
-	if err := s.Usecase.WalletTransaction(request); err != nil {
-		logrus.Error(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "transaction failed"})
+	res, err := s.Usecase.WalletTransaction(c.Request.Context(), request)
+	if err != nil {
+		metrics.ObserveTransaction(request.Operation, request.Currency, request.Amount, err)
+		abortWithError(c, err, "transaction failed")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": "true"})
+	if res.Replayed {
+		metrics.ObserveReplay(request.Operation)
+		c.Header(idempotentReplayedHeader, "true")
+	} else {
+		metrics.ObserveTransaction(request.Operation, res.Currency, request.Amount, nil)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) Transfer(c *gin.Context) {
+	var request models.TransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+
+	logrus.Debugf("Parsed request: %s -> %s %d", request.FromWalletID, request.ToWalletID, request.Amount)
+
+	res, err := s.Usecase.Transfer(c.Request.Context(), request)
+	metrics.ObserveTransaction(metrics.OperationTransfer, res.FromCurrency, request.Amount, err)
+	if err != nil {
+		abortWithError(c, err, "transfer failed")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) ReverseTransaction(c *gin.Context) {
+	var request models.ReverseTransactionRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			logrus.WithError(err).Error("error binding JSON")
+			abortWithBadRequest(c, "invalid JSON format")
+			return
+		}
+	}
+	request.TransactionID = c.Param("id")
+
+	logrus.Debugf("Parsed request: %s", request.TransactionID)
+
+	res, err := s.Usecase.ReverseTransaction(c.Request.Context(), request)
+	// Reversing a deposit posts a REVERSAL, reversing a withdrawal or capture
+	// a REFUND.
+	operation := res.Reversal.Operation
+	if operation == "" {
+		operation = models.OperationReversal
+	}
+	metrics.ObserveTransaction(operation, res.Reversal.Currency, res.Reversal.Amount, err)
+	if err != nil {
+		abortWithError(c, err, "failed to reverse transaction")
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
 }
 
 func (s *Server) GetBalance(c *gin.Context) {
@@ -51,17 +146,60 @@ func (s *Server) GetBalance(c *gin.Context) {
 	if id == "" {
 		err := errors.New("parametr 'id' is empty")
 		logrus.Error(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "parameter 'id' is empty"})
+		abortWithBadRequest(c, "parameter 'id' is empty")
 		return
 	}
 
 	logrus.SetLevel(logrus.DebugLevel)
 	logrus.Debugf("Parsed request: %s", id)
 
-	res, err := s.Usecase.GetBalance(id)
+	res, err := s.Usecase.GetBalance(c.Request.Context(), id)
 	if err != nil {
-		logrus.Error(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get balance"})
+		abortWithError(c, err, "failed to get balance")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) ReconcileWallet(c *gin.Context) {
+	id := c.Param("id")
+
+	logrus.Debugf("Parsed request: %s", id)
+
+	res, err := s.Usecase.ReconcileWallet(c.Request.Context(), id)
+	if err != nil {
+		abortWithError(c, err, "failed to reconcile wallet")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetTrialBalance(c *gin.Context) {
+	res, err := s.Usecase.GetTrialBalance(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err, "failed to get trial balance")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetTransactions(c *gin.Context) {
+	var request models.GetTransactionsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logrus.WithError(err).Error("error binding query")
+		abortWithBadRequest(c, "invalid query parameters")
+		return
+	}
+	request.WalletID = c.Param("id")
+
+	logrus.Debugf("Parsed request: %+v", request)
+
+	res, err := s.Usecase.GetTransactions(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to get transactions")
 		return
 	}
 
@@ -69,13 +207,430 @@ func (s *Server) GetBalance(c *gin.Context) {
 }
 
 func (s *Server) CreateWallet(c *gin.Context) {
-	
-	err := s.Usecase.CreateWallet()
+	var request models.CreateWalletRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			logrus.WithError(err).Error("error binding JSON")
+			abortWithBadRequest(c, "invalid JSON format")
+			return
+		}
+	}
+
+	res, err := s.Usecase.CreateWallet(c.Request.Context(), request)
 	if err != nil {
-		logrus.Error(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to create wallet"})
+		abortWithError(c, err, "failed to create wallet")
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (s *Server) CreateHold(c *gin.Context) {
+	var request models.CreateHoldRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+	request.WalletID = c.Param("id")
+
+	logrus.Debugf("Parsed request: %s %d", request.WalletID, request.Amount)
+
+	res, err := s.Usecase.CreateHold(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to create hold")
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (s *Server) GetHold(c *gin.Context) {
+	res, err := s.Usecase.GetHold(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to get hold")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) CaptureHold(c *gin.Context) {
+	var request models.CaptureHoldRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			logrus.WithError(err).Error("error binding JSON")
+			abortWithBadRequest(c, "invalid JSON format")
+			return
+		}
+	}
+	request.HoldID = c.Param("id")
+
+	res, err := s.Usecase.CaptureHold(c.Request.Context(), request)
+	metrics.ObserveTransaction(models.OperationCapture, res.Transaction.Currency, res.Transaction.Amount, err)
+	if err != nil {
+		abortWithError(c, err, "failed to capture hold")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) VoidHold(c *gin.Context) {
+	res, err := s.Usecase.VoidHold(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to void hold")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) CreateQuote(c *gin.Context) {
+	var request models.CreateQuoteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+
+	logrus.Debugf("Parsed request: %s -> %s %d", request.FromCurrency, request.ToCurrency, request.Amount)
+
+	res, err := s.Usecase.CreateQuote(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to create quote")
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (s *Server) GetQuote(c *gin.Context) {
+	res, err := s.Usecase.GetQuote(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to get quote")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) FreezeWallet(c *gin.Context) {
+	s.changeWalletStatus(c, s.Usecase.FreezeWallet, "failed to freeze wallet")
+}
+
+func (s *Server) UnfreezeWallet(c *gin.Context) {
+	s.changeWalletStatus(c, s.Usecase.UnfreezeWallet, "failed to unfreeze wallet")
+}
+
+func (s *Server) CloseWallet(c *gin.Context) {
+	s.changeWalletStatus(c, s.Usecase.CloseWallet, "failed to close wallet")
+}
+
+func (s *Server) changeWalletStatus(
+	c *gin.Context,
+	change func(context.Context, models.WalletStatusRequest) (models.Wallet, error),
+	internalMessage string,
+) {
+	var request models.WalletStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+	request.WalletID = c.Param("id")
+
+	logrus.Debugf("Parsed request: %s %q", request.WalletID, request.Reason)
+
+	res, err := change(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, internalMessage)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetWalletStatusHistory(c *gin.Context) {
+	res, err := s.Usecase.GetWalletStatusHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to get wallet status history")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetWalletLimits(c *gin.Context) {
+	res, err := s.Usecase.GetWalletLimits(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to get wallet limits")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) SetWalletLimits(c *gin.Context) {
+	var request models.SetLimitsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+	request.WalletID = c.Param("id")
+
+	res, err := s.Usecase.SetWalletLimits(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to set wallet limits")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) SetTierLimits(c *gin.Context) {
+	var request models.SetLimitsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+	request.Tier = c.Param("tier")
+
+	res, err := s.Usecase.SetTierLimits(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to set tier limits")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) SetWalletTier(c *gin.Context) {
+	var request models.SetTierRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+	request.WalletID = c.Param("id")
+
+	res, err := s.Usecase.SetWalletTier(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to set wallet tier")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) SetCreditLimit(c *gin.Context) {
+	var request models.SetCreditLimitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+	request.WalletID = c.Param("id")
+
+	res, err := s.Usecase.SetCreditLimit(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to set credit limit")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": "true"})
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetWalletOwners(c *gin.Context) {
+	res, err := s.Usecase.GetWalletOwners(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to get wallet owners")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) AddWalletOwner(c *gin.Context) {
+	request := models.WalletOwnerRequest{WalletID: c.Param("id"), Subject: c.Param("subject")}
+
+	res, err := s.Usecase.AddWalletOwner(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to add wallet owner")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) RemoveWalletOwner(c *gin.Context) {
+	request := models.WalletOwnerRequest{WalletID: c.Param("id"), Subject: c.Param("subject")}
+
+	if err := s.Usecase.RemoveWalletOwner(c.Request.Context(), request); err != nil {
+		abortWithError(c, err, "failed to remove wallet owner")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) CreateWebhook(c *gin.Context) {
+	var request models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+
+	res, err := s.Usecase.CreateWebhook(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (s *Server) GetWebhooks(c *gin.Context) {
+	res, err := s.Usecase.GetWebhooks(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err, "failed to get webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetWebhook(c *gin.Context) {
+	res, err := s.Usecase.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) DeleteWebhook(c *gin.Context) {
+	res, err := s.Usecase.DeleteWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetWebhookDeliveries(c *gin.Context) {
+	var request models.GetWebhookDeliveriesRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logrus.WithError(err).Error("error binding query")
+		abortWithBadRequest(c, "invalid query parameters")
+		return
+	}
+
+	res, err := s.Usecase.GetWebhookDeliveries(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to get webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetWebhookDelivery(c *gin.Context) {
+	res, err := s.Usecase.GetWebhookDelivery(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to get webhook delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) ReplayWebhookDelivery(c *gin.Context) {
+	res, err := s.Usecase.ReplayWebhookDelivery(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to replay webhook delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) CreateAPIKey(c *gin.Context) {
+	var request models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logrus.WithError(err).Error("error binding JSON")
+		abortWithBadRequest(c, "invalid JSON format")
+		return
+	}
+
+	res, err := s.Usecase.CreateAPIKey(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (s *Server) GetAPIKeys(c *gin.Context) {
+	res, err := s.Usecase.GetAPIKeys(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err, "failed to get API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) RotateAPIKey(c *gin.Context) {
+	var request models.RotateAPIKeyRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			logrus.WithError(err).Error("error binding JSON")
+			abortWithBadRequest(c, "invalid JSON format")
+			return
+		}
+	}
+	request.KeyID = c.Param("id")
+
+	res, err := s.Usecase.RotateAPIKey(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to rotate API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (s *Server) RevokeAPIKey(c *gin.Context) {
+	res, err := s.Usecase.RevokeAPIKey(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err, "failed to revoke API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) GetAPIKeyUsage(c *gin.Context) {
+	var request models.GetAPIKeyUsageRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logrus.WithError(err).Error("error binding query")
+		abortWithBadRequest(c, "invalid query parameters")
+		return
+	}
+	request.KeyID = c.Param("id")
+
+	res, err := s.Usecase.GetAPIKeyUsage(c.Request.Context(), request)
+	if err != nil {
+		abortWithError(c, err, "failed to get API key usage")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+func (u *Usecase) SetCreditLimit(ctx context.Context, data models.SetCreditLimitRequest) (models.Wallet, error) {
+	id, err := u.parsedUUID(data.WalletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.SetCreditLimit")
+		return models.Wallet{}, err
+	}
+	if data.CreditLimit == nil || *data.CreditLimit < 0 {
+		err = invalidRequest("credit_limit must be >= 0")
+		return models.Wallet{}, errors.Wrap(err, "usecase.SetCreditLimit")
+	}
+
+	return u.pgPepo.SetCreditLimit(ctx, id, *data.CreditLimit)
+}
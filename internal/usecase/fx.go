@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/fx"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+// CreateQuote locks the current rate for converting data.Amount for the
+// configured quote TTL.
+func (u *Usecase) CreateQuote(ctx context.Context, data models.CreateQuoteRequest) (models.Quote, error) {
+	if err := u.parsedAmount(data.Amount); err != nil {
+		err = errors.Wrap(err, "usecase.CreateQuote")
+		return models.Quote{}, err
+	}
+	for _, currency := range []string{data.FromCurrency, data.ToCurrency} {
+		if _, ok := models.CurrencyExponent(currency); !ok {
+			err := invalidRequest("unsupported currency %q", currency)
+			return models.Quote{}, errors.Wrap(err, "usecase.CreateQuote")
+		}
+	}
+	if data.FromCurrency == data.ToCurrency {
+		err := invalidRequest("from_currency and to_currency must differ")
+		return models.Quote{}, errors.Wrap(err, "usecase.CreateQuote")
+	}
+
+	rate, converted, err := u.convert(ctx, data.Amount, data.FromCurrency, data.ToCurrency)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.CreateQuote")
+		return models.Quote{}, err
+	}
+
+	return u.pgPepo.CreateQuote(ctx, models.Quote{
+		FromCurrency:    data.FromCurrency,
+		ToCurrency:      data.ToCurrency,
+		Rate:            fx.FormatRate(rate),
+		Amount:          data.Amount,
+		ConvertedAmount: converted,
+		ExpiresAt:       time.Now().Add(u.quoteTTL),
+	})
+}
+
+func (u *Usecase) GetQuote(ctx context.Context, quoteID string) (models.Quote, error) {
+	id, err := u.parsedUUID(quoteID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetQuote")
+		return models.Quote{}, err
+	}
+
+	return u.pgPepo.GetQuote(ctx, id)
+}
+
+// conversion returns the exchange to apply to a transfer, or nil when both
+// wallets hold the same currency. A quote, when given, fixes the rate;
+// otherwise the current rate is used.
+func (u *Usecase) conversion(ctx context.Context, source, destination models.Wallet, data models.TransferRequest) (*models.Conversion, error) {
+	if source.Currency == destination.Currency {
+		if data.QuoteID != "" {
+			return nil, invalidRequest("quote_id is only allowed for cross-currency transfers")
+		}
+		return nil, nil
+	}
+
+	conv := &models.Conversion{
+		FromCurrency: source.Currency,
+		ToCurrency:   destination.Currency,
+	}
+
+	if data.QuoteID == "" {
+		rate, converted, err := u.convert(ctx, data.Amount, conv.FromCurrency, conv.ToCurrency)
+		if err != nil {
+			return nil, err
+		}
+		conv.Rate = fx.FormatRate(rate)
+		conv.Amount = converted
+		return conv, nil
+	}
+
+	quoteID, err := u.parsedUUID(data.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := u.pgPepo.GetQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.FromCurrency != conv.FromCurrency || quote.ToCurrency != conv.ToCurrency {
+		return nil, errors.Wrapf(models.ErrCurrencyMismatch, "quote is for %s/%s", quote.FromCurrency, quote.ToCurrency)
+	}
+	if quote.Amount != data.Amount {
+		return nil, invalidRequest("quote is for amount %d", quote.Amount)
+	}
+	if quote.UsedAt != nil || !quote.ExpiresAt.After(time.Now()) {
+		return nil, models.ErrQuoteExpired
+	}
+
+	conv.Rate = quote.Rate
+	conv.Amount = quote.ConvertedAmount
+	conv.QuoteID = uuid.NullUUID{UUID: quoteID, Valid: true}
+	return conv, nil
+}
+
+func (u *Usecase) convert(ctx context.Context, amount int64, from, to string) (*big.Rat, int64, error) {
+	rate, err := u.rates.Rate(ctx, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	rate = fx.RoundRate(rate)
+	converted, err := fx.Convert(amount, from, to, rate)
+	if err != nil {
+		return nil, 0, err
+	}
+	if converted <= 0 {
+		return nil, 0, errors.Wrapf(models.ErrInvalidAmount, "%d %s converts to nothing in %s", amount, from, to)
+	}
+	return rate, converted, nil
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const (
+	defaultHoldTTL = 24 * time.Hour
+	maxHoldTTL     = 30 * 24 * time.Hour
+)
+
+func (u *Usecase) CreateHold(ctx context.Context, data models.CreateHoldRequest) (models.Hold, error) {
+	id, err := u.parsedUUID(data.WalletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.CreateHold")
+		return models.Hold{}, err
+	}
+
+	if err = u.parsedAmount(data.Amount); err != nil {
+		err = errors.Wrap(err, "usecase.CreateHold")
+		return models.Hold{}, err
+	}
+
+	ttl := defaultHoldTTL
+	if data.ExpiresIn != 0 {
+		ttl = time.Duration(data.ExpiresIn) * time.Second
+		if data.ExpiresIn < 0 || ttl > maxHoldTTL {
+			err = invalidRequest("expires_in must be between 1 and %d seconds", int64(maxHoldTTL/time.Second))
+			return models.Hold{}, errors.Wrap(err, "usecase.CreateHold")
+		}
+	}
+
+	return u.pgPepo.CreateHold(ctx, id, data.Amount, time.Now().Add(ttl))
+}
+
+func (u *Usecase) GetHold(ctx context.Context, holdID string) (models.Hold, error) {
+	id, err := u.parsedUUID(holdID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetHold")
+		return models.Hold{}, err
+	}
+
+	return u.pgPepo.GetHold(ctx, id)
+}
+
+// CaptureHold captures the full hold unless a smaller amount is given.
+func (u *Usecase) CaptureHold(ctx context.Context, data models.CaptureHoldRequest) (models.CaptureHoldResponse, error) {
+	id, err := u.parsedUUID(data.HoldID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.CaptureHold")
+		return models.CaptureHoldResponse{}, err
+	}
+
+	if data.Amount != nil {
+		if err = u.parsedAmount(*data.Amount); err != nil {
+			err = errors.Wrap(err, "usecase.CaptureHold")
+			return models.CaptureHoldResponse{}, err
+		}
+	}
+
+	hold, err := u.pgPepo.GetHold(ctx, id)
+	if err != nil {
+		return models.CaptureHoldResponse{}, err
+	}
+
+	amount := hold.Amount
+	if data.Amount != nil {
+		amount = *data.Amount
+	}
+	return u.pgPepo.CaptureHold(ctx, id, amount)
+}
+
+func (u *Usecase) VoidHold(ctx context.Context, holdID string) (models.Hold, error) {
+	id, err := u.parsedUUID(holdID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.VoidHold")
+		return models.Hold{}, err
+	}
+
+	return u.pgPepo.VoidHold(ctx, id)
+}
+
+func (u *Usecase) ExpireHolds(ctx context.Context) (int64, error) {
+	return u.pgPepo.ExpireHolds(ctx)
+}
+
+// RunHoldExpiry releases expired holds every interval until ctx is done.
+func RunHoldExpiry(ctx context.Context, uc UseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.ExpireHolds(ctx); err != nil {
+				logrus.WithError(err).Error("Failed to expire holds")
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const defaultTier = "STANDARD"
+
+var tierPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+
+func (u *Usecase) GetWalletLimits(ctx context.Context, walletID string) (models.Limits, error) {
+	id, err := u.parsedUUID(walletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetWalletLimits")
+		return models.Limits{}, err
+	}
+	if err = u.checkOwner(ctx, id); err != nil {
+		err = errors.Wrap(err, "usecase.GetWalletLimits")
+		return models.Limits{}, err
+	}
+
+	wallet, err := u.pgPepo.GetWallet(ctx, id)
+	if err != nil {
+		return models.Limits{}, err
+	}
+	return wallet.Limits, nil
+}
+
+// SetWalletLimits replaces the limits set on a single wallet and returns the
+// limits now in effect for it.
+func (u *Usecase) SetWalletLimits(ctx context.Context, data models.SetLimitsRequest) (models.Limits, error) {
+	id, err := u.parsedUUID(data.WalletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.SetWalletLimits")
+		return models.Limits{}, err
+	}
+	if err = parsedLimits(data.Limits); err != nil {
+		err = errors.Wrap(err, "usecase.SetWalletLimits")
+		return models.Limits{}, err
+	}
+
+	if err = u.pgPepo.SetWalletLimits(ctx, id, data.Limits); err != nil {
+		return models.Limits{}, err
+	}
+
+	wallet, err := u.pgPepo.GetWallet(ctx, id)
+	if err != nil {
+		return models.Limits{}, err
+	}
+	return wallet.Limits, nil
+}
+
+func (u *Usecase) SetTierLimits(ctx context.Context, data models.SetLimitsRequest) (models.Limits, error) {
+	if !tierPattern.MatchString(data.Tier) {
+		err := invalidRequest("invalid tier %q", data.Tier)
+		return models.Limits{}, errors.Wrap(err, "usecase.SetTierLimits")
+	}
+	if err := parsedLimits(data.Limits); err != nil {
+		err = errors.Wrap(err, "usecase.SetTierLimits")
+		return models.Limits{}, err
+	}
+
+	if err := u.pgPepo.SetTierLimits(ctx, data.Tier, data.Limits); err != nil {
+		return models.Limits{}, err
+	}
+	return data.Limits, nil
+}
+
+func (u *Usecase) SetWalletTier(ctx context.Context, data models.SetTierRequest) (models.Wallet, error) {
+	id, err := u.parsedUUID(data.WalletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.SetWalletTier")
+		return models.Wallet{}, err
+	}
+	if !tierPattern.MatchString(data.Tier) {
+		err = invalidRequest("invalid tier %q", data.Tier)
+		return models.Wallet{}, errors.Wrap(err, "usecase.SetWalletTier")
+	}
+
+	return u.pgPepo.SetWalletTier(ctx, id, data.Tier)
+}
+
+func parsedLimits(limits models.Limits) error {
+	if limits.MaxSingleAmount != nil && *limits.MaxSingleAmount <= 0 {
+		return invalidRequest("max_single_amount must be > 0")
+	}
+	if limits.DailyWithdrawalCap != nil && *limits.DailyWithdrawalCap < 0 {
+		return invalidRequest("daily_withdrawal_cap must be >= 0")
+	}
+	if limits.MonthlyWithdrawalCap != nil && *limits.MonthlyWithdrawalCap < 0 {
+		return invalidRequest("monthly_withdrawal_cap must be >= 0")
+	}
+	if (limits.MaxOperations == nil) != (limits.OperationsWindowSeconds == nil) {
+		return invalidRequest("max_operations and operations_window_seconds must be set together")
+	}
+	if limits.MaxOperations != nil && (*limits.MaxOperations <= 0 || *limits.OperationsWindowSeconds <= 0) {
+		return invalidRequest("max_operations and operations_window_seconds must be > 0")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/outbox"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
+)
+
+// outboxLease hides claimed events from other relays while they are being
+// published.
+const outboxLease = time.Minute
+
+// RelayOutbox publishes up to limit due outbox events and returns how many
+// were published. Events are claimed in a short transaction and published
+// outside of it. A failed event is retried according to policy and
+// dead-lettered once it runs out of attempts; it does not stop the rest of
+// the batch.
+func (u *Usecase) RelayOutbox(ctx context.Context, publisher outbox.Publisher, policy webhook.RetryPolicy, limit int) (int, error) {
+	events, err := u.pgPepo.ClaimOutboxEvents(ctx, limit, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var published int
+	for _, event := range events {
+		publishErr := publisher.Publish(ctx, event)
+		if publishErr == nil {
+			if err := u.pgPepo.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+				return published, err
+			}
+			published++
+			continue
+		}
+
+		attempts := event.Attempts + 1
+		dead := attempts >= policy.MaxAttempts
+		logrus.WithError(publishErr).WithFields(logrus.Fields{
+			"event_id": event.ID,
+			"attempts": attempts,
+			"dead":     dead,
+		}).Warn("Failed to publish outbox event")
+		next := time.Now().Add(policy.Backoff(attempts))
+		if err := u.pgPepo.RecordOutboxFailure(ctx, event.ID, publishErr.Error(), next, dead); err != nil {
+			return published, err
+		}
+	}
+	return published, nil
+}
+
+func (u *Usecase) GetOutboxLag(ctx context.Context) (models.OutboxLag, error) {
+	return u.pgPepo.GetOutboxLag(ctx)
+}
+
+// RunOutboxRelay drains the outbox every interval until ctx is done. A full
+// batch is followed by another one right away.
+func RunOutboxRelay(ctx context.Context, uc UseCase, publisher outbox.Publisher, policy webhook.RetryPolicy, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				published, err := uc.RelayOutbox(ctx, publisher, policy, batchSize)
+				if err != nil {
+					logrus.WithError(err).Error("Failed to relay outbox events")
+				}
+				if err != nil || published < batchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/auth"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const maxSubjectLength = 255
+
+// checkOwner rejects requests made for an end user on a wallet they do not
+// own. Such wallets are reported as not found, so their IDs cannot be probed.
+// Requests without an end user, made with an API key, are not restricted.
+func (u *Usecase) checkOwner(ctx context.Context, id uuid.UUID) error {
+	subject, ok := auth.SubjectFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	owner, err := u.pgPepo.IsWalletOwner(ctx, id, subject)
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return errors.Wrapf(models.ErrWalletNotFound, "wallet %s is not owned by %q", id, subject)
+	}
+	return nil
+}
+
+func (u *Usecase) GetWalletOwners(ctx context.Context, walletID string) ([]models.WalletOwner, error) {
+	id, err := u.parsedUUID(walletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetWalletOwners")
+		return nil, err
+	}
+
+	return u.pgPepo.GetWalletOwners(ctx, id)
+}
+
+func (u *Usecase) AddWalletOwner(ctx context.Context, data models.WalletOwnerRequest) (models.WalletOwner, error) {
+	id, err := u.parsedWalletOwner(data)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.AddWalletOwner")
+		return models.WalletOwner{}, err
+	}
+
+	return u.pgPepo.AddWalletOwner(ctx, id, data.Subject)
+}
+
+func (u *Usecase) RemoveWalletOwner(ctx context.Context, data models.WalletOwnerRequest) error {
+	id, err := u.parsedWalletOwner(data)
+	if err != nil {
+		return errors.Wrap(err, "usecase.RemoveWalletOwner")
+	}
+
+	return u.pgPepo.RemoveWalletOwner(ctx, id, data.Subject)
+}
+
+func (u *Usecase) parsedWalletOwner(data models.WalletOwnerRequest) (uuid.UUID, error) {
+	id, err := u.parsedUUID(data.WalletID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if data.Subject == "" || len(data.Subject) > maxSubjectLength {
+		return uuid.Nil, invalidRequest("subject must be 1 to %d characters", maxSubjectLength)
+	}
+	return id, nil
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const maxStatusReasonLength = 1024
+
+func (u *Usecase) FreezeWallet(ctx context.Context, data models.WalletStatusRequest) (models.Wallet, error) {
+	res, err := u.changeWalletStatus(ctx, data, models.WalletStatusFrozen, data.BlockDeposits)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.FreezeWallet")
+		return models.Wallet{}, err
+	}
+	return res, nil
+}
+
+func (u *Usecase) UnfreezeWallet(ctx context.Context, data models.WalletStatusRequest) (models.Wallet, error) {
+	res, err := u.changeWalletStatus(ctx, data, models.WalletStatusActive, false)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.UnfreezeWallet")
+		return models.Wallet{}, err
+	}
+	return res, nil
+}
+
+func (u *Usecase) CloseWallet(ctx context.Context, data models.WalletStatusRequest) (models.Wallet, error) {
+	res, err := u.changeWalletStatus(ctx, data, models.WalletStatusClosed, true)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.CloseWallet")
+		return models.Wallet{}, err
+	}
+	return res, nil
+}
+
+func (u *Usecase) GetWalletStatusHistory(ctx context.Context, walletID string) ([]models.WalletStatusChange, error) {
+	id, err := u.parsedUUID(walletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetWalletStatusHistory")
+		return nil, err
+	}
+
+	if _, err = u.pgPepo.GetWallet(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return u.pgPepo.GetWalletStatusHistory(ctx, id)
+}
+
+func (u *Usecase) changeWalletStatus(ctx context.Context, data models.WalletStatusRequest, status string, blockDeposits bool) (models.Wallet, error) {
+	id, err := u.parsedUUID(data.WalletID)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+	if data.Reason == "" || len(data.Reason) > maxStatusReasonLength {
+		return models.Wallet{}, invalidRequest("reason is required and must be at most %d characters", maxStatusReasonLength)
+	}
+
+	return u.pgPepo.ChangeWalletStatus(ctx, id, status, blockDeposits, data.Reason)
+}
@@ -1,11 +1,22 @@
 package usecase
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
+	"github.com/SerzhLimon/PaymentService/internal/fx"
 	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/outbox"
 	"github.com/SerzhLimon/PaymentService/internal/repository"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
 )
 
 type operation int
@@ -16,70 +27,415 @@ const (
 	withdraw operation = 2
 )
 
+const (
+	defaultTransactionsLimit = 20
+	maxTransactionsLimit     = 100
+
+	maxIdempotencyKeyLength = 255
+	maxOwnerRefLength       = 255
+
+	defaultCurrency = "USD"
+)
+
 type Usecase struct {
-	pgPepo repository.Repository
+	pgPepo   repository.Repository
+	rates    fx.RateProvider
+	quoteTTL time.Duration
 }
 
 type UseCase interface {
-	WalletTransaction(models.WalletTransaction) error
-	GetBalance(id string) (models.GetBalanceResponse, error)
-	CreateWallet() error
+	WalletTransaction(context.Context, models.WalletTransaction) (models.Transaction, error)
+	Transfer(context.Context, models.TransferRequest) (models.Transfer, error)
+	GetBalance(ctx context.Context, id string) (models.GetBalanceResponse, error)
+	GetTransactions(context.Context, models.GetTransactionsRequest) (models.TransactionList, error)
+	CreateWallet(context.Context, models.CreateWalletRequest) (models.Wallet, error)
+	ReconcileWallet(ctx context.Context, id string) (models.Reconciliation, error)
+	GetTrialBalance(context.Context) (models.TrialBalance, error)
+	CreateQuote(context.Context, models.CreateQuoteRequest) (models.Quote, error)
+	GetQuote(ctx context.Context, id string) (models.Quote, error)
+	ReverseTransaction(context.Context, models.ReverseTransactionRequest) (models.Reversal, error)
+	FreezeWallet(context.Context, models.WalletStatusRequest) (models.Wallet, error)
+	UnfreezeWallet(context.Context, models.WalletStatusRequest) (models.Wallet, error)
+	CloseWallet(context.Context, models.WalletStatusRequest) (models.Wallet, error)
+	GetWalletStatusHistory(ctx context.Context, id string) ([]models.WalletStatusChange, error)
+	GetWalletLimits(ctx context.Context, id string) (models.Limits, error)
+	SetWalletLimits(context.Context, models.SetLimitsRequest) (models.Limits, error)
+	SetTierLimits(context.Context, models.SetLimitsRequest) (models.Limits, error)
+	SetWalletTier(context.Context, models.SetTierRequest) (models.Wallet, error)
+	SetCreditLimit(context.Context, models.SetCreditLimitRequest) (models.Wallet, error)
+	GetWalletOwners(ctx context.Context, id string) ([]models.WalletOwner, error)
+	AddWalletOwner(context.Context, models.WalletOwnerRequest) (models.WalletOwner, error)
+	RemoveWalletOwner(context.Context, models.WalletOwnerRequest) error
+	CreateHold(context.Context, models.CreateHoldRequest) (models.Hold, error)
+	GetHold(ctx context.Context, id string) (models.Hold, error)
+	CaptureHold(context.Context, models.CaptureHoldRequest) (models.CaptureHoldResponse, error)
+	VoidHold(ctx context.Context, id string) (models.Hold, error)
+	ExpireHolds(context.Context) (int64, error)
+	RelayOutbox(ctx context.Context, publisher outbox.Publisher, policy webhook.RetryPolicy, limit int) (int, error)
+	GetOutboxLag(context.Context) (models.OutboxLag, error)
+	CreateWebhook(context.Context, models.CreateWebhookRequest) (models.WebhookEndpoint, error)
+	GetWebhooks(context.Context) ([]models.WebhookEndpoint, error)
+	GetWebhook(ctx context.Context, id string) (models.WebhookEndpoint, error)
+	DeleteWebhook(ctx context.Context, id string) (models.WebhookEndpoint, error)
+	GetWebhookDeliveries(context.Context, models.GetWebhookDeliveriesRequest) ([]models.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id string) (models.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, id string) (models.WebhookDelivery, error)
+	DeliverWebhooks(ctx context.Context, sender webhook.Sender, policy webhook.RetryPolicy, limit int) (int, error)
+	CreateAPIKey(context.Context, models.CreateAPIKeyRequest) (models.APIKey, error)
+	GetAPIKeys(context.Context) ([]models.APIKey, error)
+	RotateAPIKey(context.Context, models.RotateAPIKeyRequest) (models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error)
+	GetAPIKeyUsage(context.Context, models.GetAPIKeyUsageRequest) ([]models.APIKeyUsage, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (models.APIKey, error)
+	RecordAPIKeyUsage(context.Context, models.APIKeyUsage) error
+	BootstrapAPIKey(ctx context.Context, key string) error
 }
 
-func NewUsecase(pgPepo repository.Repository) UseCase {
-	return &Usecase{pgPepo: pgPepo}
+func NewUsecase(pgPepo repository.Repository, rates fx.RateProvider, quoteTTL time.Duration) UseCase {
+	return &Usecase{pgPepo: pgPepo, rates: rates, quoteTTL: quoteTTL}
 }
 
-func (u *Usecase) WalletTransaction(data models.WalletTransaction) error {
+func (u *Usecase) WalletTransaction(ctx context.Context, data models.WalletTransaction) (models.Transaction, error) {
 	var err error
 	id, err := u.parsedUUID(data.WalletID)
 	if err != nil {
-		err = errors.Errorf("usecase.WalletTransaction %v", err)
-		return err
+		err = errors.Wrap(err, "usecase.WalletTransaction")
+		return models.Transaction{}, err
+	}
+
+	if err = u.checkOwner(ctx, id); err != nil {
+		err = errors.Wrap(err, "usecase.WalletTransaction")
+		return models.Transaction{}, err
 	}
 
 	if err = u.parsedAmount(data.Amount); err != nil {
-		err = errors.Errorf("usecase.WalletTransaction %v", err)
-		return err
+		err = errors.Wrap(err, "usecase.WalletTransaction")
+		return models.Transaction{}, err
+	}
+
+	idem, err := u.parsedIdempotency(data.IdempotencyKey, id, data)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.WalletTransaction")
+		return models.Transaction{}, err
 	}
 
 	operation := u.parsedOperation(data.Operation)
-	switch operation {
-	case deposit:
-		return u.pgPepo.WalletTransactionDeposit(id, data.Amount)
-	case withdraw:
-		return u.pgPepo.WalletTransactionWithdraw(id, data.Amount)
-	default:
-		err = errors.New("usecase.WalletTransaction: unknown transaction")
+	if operation == unknown {
+		err = errors.Wrap(models.ErrUnknownOperation, "usecase.WalletTransaction")
+		return models.Transaction{}, err
 	}
 
-	return err
+	if data.Currency != "" {
+		if _, ok := models.CurrencyExponent(data.Currency); !ok {
+			err = invalidRequest("unsupported currency %q", data.Currency)
+			return models.Transaction{}, errors.Wrap(err, "usecase.WalletTransaction")
+		}
+	}
+
+	// Status and limits are checked by the repository after the idempotency
+	// lookup, so that a retried request gets its stored result.
+	if data.Currency != "" {
+		wallet, err := u.pgPepo.GetWallet(ctx, id)
+		if err != nil {
+			return models.Transaction{}, err
+		}
+		if wallet.Currency != data.Currency {
+			err = errors.Wrapf(models.ErrCurrencyMismatch, "wallet is in %s, got %s", wallet.Currency, data.Currency)
+			return models.Transaction{}, errors.Wrap(err, "usecase.WalletTransaction")
+		}
+	}
+
+	if operation == withdraw {
+		return u.pgPepo.WalletTransactionWithdraw(ctx, id, data.Amount, idem)
+	}
+	return u.pgPepo.WalletTransactionDeposit(ctx, id, data.Amount, idem)
 }
 
-func (u *Usecase) GetBalance(walletID string) (models.GetBalanceResponse, error) {
+func (u *Usecase) Transfer(ctx context.Context, data models.TransferRequest) (models.Transfer, error) {
+	from, err := u.parsedUUID(data.FromWalletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.Transfer from_wallet_id")
+		return models.Transfer{}, err
+	}
+
+	to, err := u.parsedUUID(data.ToWalletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.Transfer to_wallet_id")
+		return models.Transfer{}, err
+	}
+
+	if from == to {
+		err = errors.Wrap(invalidRequest("source and destination wallets must differ"), "usecase.Transfer")
+		return models.Transfer{}, err
+	}
+
+	if err = u.parsedAmount(data.Amount); err != nil {
+		err = errors.Wrap(err, "usecase.Transfer")
+		return models.Transfer{}, err
+	}
+
+	if err = u.checkOwner(ctx, from); err != nil {
+		err = errors.Wrap(err, "usecase.Transfer")
+		return models.Transfer{}, err
+	}
+
+	source, err := u.pgPepo.GetWallet(ctx, from)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	destination, err := u.pgPepo.GetWallet(ctx, to)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	conv, err := u.conversion(ctx, source, destination, data)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.Transfer")
+		return models.Transfer{}, err
+	}
+
+	return u.pgPepo.Transfer(ctx, from, to, data.Amount, conv)
+}
+
+func (u *Usecase) GetBalance(ctx context.Context, walletID string) (models.GetBalanceResponse, error) {
 	id, err := u.parsedUUID(walletID)
 	if err != nil {
-		err = errors.Errorf("usecase.GetBalance %v", err)
+		err = errors.Wrap(err, "usecase.GetBalance")
+		return models.GetBalanceResponse{}, err
+	}
+	if err = u.checkOwner(ctx, id); err != nil {
+		err = errors.Wrap(err, "usecase.GetBalance")
+		return models.GetBalanceResponse{}, err
+	}
+
+	res, err := u.pgPepo.GetBalance(ctx, id)
+	if err != nil {
 		return models.GetBalanceResponse{}, err
 	}
-	
-	return u.pgPepo.GetBalance(id)
+	res.LedgerFormatted = models.FormatAmount(res.Ledger, res.Currency)
+	res.AvailableFormatted = models.FormatAmount(res.Available, res.Currency)
+
+	return res, nil
+}
+
+// ReverseTransaction reverses the whole unreversed part of a transaction
+// unless a smaller amount is given.
+func (u *Usecase) ReverseTransaction(ctx context.Context, data models.ReverseTransactionRequest) (models.Reversal, error) {
+	id, err := u.parsedUUID(data.TransactionID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.ReverseTransaction")
+		return models.Reversal{}, err
+	}
+
+	var amount int64
+	if data.Amount != nil {
+		if err = u.parsedAmount(*data.Amount); err != nil {
+			err = errors.Wrap(err, "usecase.ReverseTransaction")
+			return models.Reversal{}, err
+		}
+		amount = *data.Amount
+	}
+
+	return u.pgPepo.ReverseTransaction(ctx, id, amount)
+}
+
+func (u *Usecase) ReconcileWallet(ctx context.Context, walletID string) (models.Reconciliation, error) {
+	id, err := u.parsedUUID(walletID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.ReconcileWallet")
+		return models.Reconciliation{}, err
+	}
+
+	return u.pgPepo.ReconcileWallet(ctx, id)
+}
+
+func (u *Usecase) GetTrialBalance(ctx context.Context) (models.TrialBalance, error) {
+	return u.pgPepo.GetTrialBalance(ctx)
+}
+
+func (u *Usecase) GetTransactions(ctx context.Context, data models.GetTransactionsRequest) (models.TransactionList, error) {
+	filter, err := u.parsedTransactionFilter(data)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetTransactions")
+		return models.TransactionList{}, err
+	}
+	if err = u.checkOwner(ctx, filter.WalletID); err != nil {
+		err = errors.Wrap(err, "usecase.GetTransactions")
+		return models.TransactionList{}, err
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+
+	transactions, err := u.pgPepo.GetTransactions(ctx, filter)
+	if err != nil {
+		return models.TransactionList{}, err
+	}
+
+	res := models.TransactionList{Transactions: transactions}
+	if len(transactions) > limit {
+		res.Transactions = transactions[:limit]
+		last := res.Transactions[limit-1]
+		res.NextCursor, err = encodeCursor(last)
+		if err != nil {
+			err = errors.Wrap(err, "usecase.GetTransactions")
+			return models.TransactionList{}, err
+		}
+	}
+
+	return res, nil
+}
+
+func (u *Usecase) parsedTransactionFilter(data models.GetTransactionsRequest) (models.TransactionFilter, error) {
+	var filter models.TransactionFilter
+
+	id, err := u.parsedUUID(data.WalletID)
+	if err != nil {
+		return filter, err
+	}
+	filter.WalletID = id
+
+	switch data.Operation {
+	case "":
+	case models.OperationDeposit, models.OperationWithdraw,
+		models.OperationTransferOut, models.OperationTransferIn,
+		models.OperationCapture, models.OperationRefund, models.OperationReversal:
+		filter.Operation = data.Operation
+	default:
+		return filter, errors.Wrapf(models.ErrUnknownOperation, "%q", data.Operation)
+	}
+
+	if data.MinAmount != nil && data.MaxAmount != nil && *data.MinAmount > *data.MaxAmount {
+		return filter, invalidRequest("min_amount must be <= max_amount")
+	}
+	filter.MinAmount = data.MinAmount
+	filter.MaxAmount = data.MaxAmount
+
+	if filter.From, err = parsedTime(data.From); err != nil {
+		return filter, invalidRequest("invalid from: %v", err)
+	}
+	if filter.To, err = parsedTime(data.To); err != nil {
+		return filter, invalidRequest("invalid to: %v", err)
+	}
+
+	switch data.Sort {
+	case "", "desc":
+		filter.Descending = true
+	case "asc":
+		filter.Descending = false
+	default:
+		return filter, invalidRequest("unknown sort %q", data.Sort)
+	}
+
+	if data.Cursor != "" {
+		cursor, err := decodeCursor(data.Cursor)
+		if err != nil {
+			return filter, invalidRequest("invalid cursor: %v", err)
+		}
+		filter.After = &cursor
+	}
+
+	switch {
+	case data.Limit == 0:
+		filter.Limit = defaultTransactionsLimit
+	case data.Limit < 0 || data.Limit > maxTransactionsLimit:
+		return filter, invalidRequest("limit must be between 1 and %d", maxTransactionsLimit)
+	default:
+		filter.Limit = data.Limit
+	}
+
+	return filter, nil
+}
+
+// invalidRequest annotates models.ErrInvalidRequest with the reason.
+func invalidRequest(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", models.ErrInvalidRequest, fmt.Sprintf(format, args...))
+}
+
+func parsedTime(data string) (*time.Time, error) {
+	if data == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, data)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// encodeCursor returns an opaque keyset cursor pointing right after t.
+func encodeCursor(t models.Transaction) (string, error) {
+	raw, err := json.Marshal(cursorPayload{CreatedAt: t.CreatedAt, ID: t.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(data string) (models.TransactionCursor, error) {
+	var res models.TransactionCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return res, err
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return res, err
+	}
+
+	id, err := uuid.Parse(payload.ID)
+	if err != nil {
+		return res, err
+	}
+
+	res.CreatedAt = payload.CreatedAt
+	res.ID = id
+	return res, nil
+}
+
+type cursorPayload struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
 }
 
 func (u *Usecase) parsedUUID(data string) (uuid.UUID, error) {
 	id, err := uuid.Parse(data)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, invalidRequest("%v", err)
 	}
 	return id, nil
 }
 
+// parsedIdempotency fingerprints the whole request body, so a replay is only
+// accepted when it asks for exactly the same thing. Keys are scoped to the
+// wallet, so clients cannot collide on each other's keys.
+func (u *Usecase) parsedIdempotency(key string, id uuid.UUID, data models.WalletTransaction) (models.Idempotency, error) {
+	if key == "" {
+		return models.Idempotency{}, nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return models.Idempotency{}, invalidRequest("idempotency key must be at most %d characters", maxIdempotencyKeyLength)
+	}
+
+	data.WalletID = id.String()
+	request, err := json.Marshal(data)
+	if err != nil {
+		return models.Idempotency{}, err
+	}
+	sum := sha256.Sum256(request)
+	return models.Idempotency{
+		Key:         key,
+		RequestHash: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
 func (u *Usecase) parsedOperation(data string) operation {
 	var res operation
 	switch data {
-	case "DEPOSIT":
+	case models.OperationDeposit:
 		res = deposit
-	case "WITHDRAW":
+	case models.OperationWithdraw:
 		res = withdraw
 	default:
 		res = unknown
@@ -88,15 +444,78 @@ func (u *Usecase) parsedOperation(data string) operation {
 }
 
 func (u *Usecase) parsedAmount(data int64) error {
-	if data < 0 {
-		err := errors.New("amount must be > 0")
-		return err
+	if data <= 0 {
+		return models.ErrInvalidAmount
 	}
 	return nil
 }
 
-func (u *Usecase) CreateWallet() error {
-	idstr := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
-	id, _ := uuid.Parse(idstr)
-	return u.pgPepo.CreateWallet(id)
-}
\ No newline at end of file
+func (u *Usecase) CreateWallet(ctx context.Context, data models.CreateWalletRequest) (models.Wallet, error) {
+	id := uuid.New()
+	if data.ID != "" {
+		var err error
+		if id, err = u.parsedUUID(data.ID); err != nil {
+			err = errors.Wrap(err, "usecase.CreateWallet")
+			return models.Wallet{}, err
+		}
+	}
+
+	currency, err := u.parsedCurrency(data.Currency)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.CreateWallet")
+		return models.Wallet{}, err
+	}
+
+	metadata, err := u.parsedMetadata(data.Metadata)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.CreateWallet")
+		return models.Wallet{}, err
+	}
+
+	tier := defaultTier
+	if data.Tier != "" {
+		if !tierPattern.MatchString(data.Tier) {
+			err = invalidRequest("invalid tier %q", data.Tier)
+			return models.Wallet{}, errors.Wrap(err, "usecase.CreateWallet")
+		}
+		tier = data.Tier
+	}
+
+	wallet := models.Wallet{
+		ID:       id.String(),
+		Currency: currency,
+		Tier:     tier,
+		Metadata: metadata,
+	}
+	if data.OwnerRef != "" {
+		if len(data.OwnerRef) > maxOwnerRefLength {
+			err = invalidRequest("owner_ref must be at most %d characters", maxOwnerRefLength)
+			err = errors.Wrap(err, "usecase.CreateWallet")
+			return models.Wallet{}, err
+		}
+		wallet.OwnerRef = &data.OwnerRef
+	}
+
+	return u.pgPepo.CreateWallet(ctx, wallet)
+}
+
+func (u *Usecase) parsedCurrency(data string) (string, error) {
+	if data == "" {
+		return defaultCurrency, nil
+	}
+	if _, ok := models.CurrencyExponent(data); !ok {
+		return "", invalidRequest("unsupported currency %q", data)
+	}
+	return data, nil
+}
+
+func (u *Usecase) parsedMetadata(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return json.RawMessage("{}"), nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, invalidRequest("metadata must be a JSON object")
+	}
+	return data, nil
+}
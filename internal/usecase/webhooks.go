@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
+)
+
+// webhookLease hides claimed deliveries from other workers while they are
+// being sent. It must exceed the webhook client timeout.
+const webhookLease = time.Minute
+
+func (u *Usecase) CreateWebhook(ctx context.Context, data models.CreateWebhookRequest) (models.WebhookEndpoint, error) {
+	endpoint, err := parsedWebhookEndpoint(data)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.CreateWebhook")
+		return models.WebhookEndpoint{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		err = errors.Wrap(err, "usecase.CreateWebhook")
+		return models.WebhookEndpoint{}, err
+	}
+	endpoint.Secret = "whsec_" + hex.EncodeToString(secret)
+
+	return u.pgPepo.CreateWebhookEndpoint(ctx, endpoint)
+}
+
+func (u *Usecase) GetWebhooks(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	return u.pgPepo.GetWebhookEndpoints(ctx)
+}
+
+func (u *Usecase) GetWebhook(ctx context.Context, webhookID string) (models.WebhookEndpoint, error) {
+	id, err := u.parsedUUID(webhookID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetWebhook")
+		return models.WebhookEndpoint{}, err
+	}
+
+	return u.pgPepo.GetWebhookEndpoint(ctx, id)
+}
+
+func (u *Usecase) DeleteWebhook(ctx context.Context, webhookID string) (models.WebhookEndpoint, error) {
+	id, err := u.parsedUUID(webhookID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.DeleteWebhook")
+		return models.WebhookEndpoint{}, err
+	}
+
+	return u.pgPepo.DisableWebhookEndpoint(ctx, id)
+}
+
+// GetWebhookDeliveries lists deliveries, newest first. Filtering by the DEAD
+// status shows the dead-letter queue.
+func (u *Usecase) GetWebhookDeliveries(ctx context.Context, data models.GetWebhookDeliveriesRequest) ([]models.WebhookDelivery, error) {
+	filter := models.WebhookDeliveryFilter{Status: data.Status, Limit: data.Limit}
+	if data.EndpointID != "" {
+		id, err := u.parsedUUID(data.EndpointID)
+		if err != nil {
+			err = errors.Wrap(err, "usecase.GetWebhookDeliveries")
+			return nil, err
+		}
+		filter.EndpointID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	switch data.Status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+	default:
+		err := invalidRequest("unknown delivery status %q", data.Status)
+		return nil, errors.Wrap(err, "usecase.GetWebhookDeliveries")
+	}
+
+	switch {
+	case data.Limit == 0:
+		filter.Limit = defaultTransactionsLimit
+	case data.Limit < 0 || data.Limit > maxTransactionsLimit:
+		err := invalidRequest("limit must be between 1 and %d", maxTransactionsLimit)
+		return nil, errors.Wrap(err, "usecase.GetWebhookDeliveries")
+	}
+
+	return u.pgPepo.GetWebhookDeliveries(ctx, filter)
+}
+
+func (u *Usecase) GetWebhookDelivery(ctx context.Context, deliveryID string) (models.WebhookDelivery, error) {
+	id, err := u.parsedUUID(deliveryID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.GetWebhookDelivery")
+		return models.WebhookDelivery{}, err
+	}
+
+	return u.pgPepo.GetWebhookDelivery(ctx, id)
+}
+
+func (u *Usecase) ReplayWebhookDelivery(ctx context.Context, deliveryID string) (models.WebhookDelivery, error) {
+	id, err := u.parsedUUID(deliveryID)
+	if err != nil {
+		err = errors.Wrap(err, "usecase.ReplayWebhookDelivery")
+		return models.WebhookDelivery{}, err
+	}
+
+	return u.pgPepo.ReplayWebhookDelivery(ctx, id)
+}
+
+// DeliverWebhooks sends up to limit due deliveries and returns how many were
+// delivered. Failed deliveries are retried according to policy and
+// dead-lettered once they run out of attempts.
+func (u *Usecase) DeliverWebhooks(ctx context.Context, sender webhook.Sender, policy webhook.RetryPolicy, limit int) (int, error) {
+	deliveries, err := u.pgPepo.ClaimWebhookDeliveries(ctx, limit, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for _, d := range deliveries {
+		attempt := sender.Send(ctx, d)
+		attempt.DeliveryID = d.ID
+
+		status, next := models.WebhookDeliveryDelivered, attempt.AttemptedAt
+		if attempt.Succeeded {
+			delivered++
+		} else if attempts := d.Attempts + 1; attempts >= policy.MaxAttempts {
+			status = models.WebhookDeliveryDead
+		} else {
+			status, next = models.WebhookDeliveryPending, time.Now().Add(policy.Backoff(attempts))
+		}
+
+		if err := u.pgPepo.RecordWebhookAttempt(ctx, attempt, status, next); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// RunWebhookDelivery sends due webhook deliveries every interval until ctx
+// is done.
+func RunWebhookDelivery(ctx context.Context, uc UseCase, sender webhook.Sender, policy webhook.RetryPolicy, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.DeliverWebhooks(ctx, sender, policy, batchSize); err != nil {
+				logrus.WithError(err).Error("Failed to deliver webhooks")
+			}
+		}
+	}
+}
+
+func parsedWebhookEndpoint(data models.CreateWebhookRequest) (models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+
+	target, err := url.Parse(data.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return endpoint, invalidRequest("url must be an absolute http or https URL")
+	}
+	endpoint.URL = target.String()
+
+	if data.WalletID != "" {
+		id, err := uuid.Parse(data.WalletID)
+		if err != nil {
+			return endpoint, invalidRequest("%v", err)
+		}
+		walletID := id.String()
+		endpoint.WalletID = &walletID
+	}
+
+	if len(data.EventTypes) == 0 {
+		return endpoint, invalidRequest("event_types must not be empty")
+	}
+	known := make(map[string]bool, len(models.WebhookEventTypes))
+	for _, eventType := range models.WebhookEventTypes {
+		known[eventType] = true
+	}
+	seen := make(map[string]bool, len(data.EventTypes))
+	for _, eventType := range data.EventTypes {
+		if !known[eventType] {
+			return endpoint, invalidRequest("unknown event type %q", eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			endpoint.EventTypes = append(endpoint.EventTypes, eventType)
+		}
+	}
+	return endpoint, nil
+}
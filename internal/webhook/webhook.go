@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Sign returns the signature header value for body sent at timestamp. The
+// signature is the hex HMAC-SHA256 of "<unix timestamp>.<body>" keyed with
+// the endpoint secret, so receivers can reject replayed requests by age.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sender makes one delivery attempt and reports its outcome.
+type Sender interface {
+	Send(ctx context.Context, delivery models.WebhookDelivery) models.WebhookAttempt
+}
+
+// Client sends signed deliveries over HTTP. Only 2xx responses count as
+// delivered.
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(timeout time.Duration) *Client {
+	return &Client{http: &http.Client{Timeout: timeout}}
+}
+
+func (c *Client) Send(ctx context.Context, delivery models.WebhookDelivery) models.WebhookAttempt {
+	start := time.Now()
+	attempt := models.WebhookAttempt{DeliveryID: delivery.ID, AttemptedAt: start}
+
+	statusCode, err := c.post(ctx, delivery, start)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if err != nil {
+		message := err.Error()
+		attempt.Error = &message
+		return attempt
+	}
+	attempt.Succeeded = true
+	return attempt
+}
+
+func (c *Client) post(ctx context.Context, delivery models.WebhookDelivery, now time.Time) (int, error) {
+	body, err := json.Marshal(models.WebhookEvent{
+		ID:        "evt_" + strconv.FormatInt(delivery.EventID, 10),
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, now, body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// RetryPolicy spaces failed attempts with exponential backoff: the n-th
+// retry waits BaseDelay * 2^(n-1), capped at MaxDelay. A delivery that has
+// failed MaxAttempts times is dead-lettered.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
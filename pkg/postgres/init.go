@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	"github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 
 	"github.com/SerzhLimon/PaymentService/config"
 )
@@ -18,7 +20,10 @@ func InitPostgresClient(cfg config.PostgresConfig) (*sql.DB, error) {
 	options := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.DBName, cfg.Password, cfg.SSLMode)
 
-	database, err := sql.Open("postgres", options)
+	database, err := otelsql.Open("postgres", options,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"host":    cfg.Host,
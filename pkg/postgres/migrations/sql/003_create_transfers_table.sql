@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    from_wallet_id UUID NOT NULL REFERENCES wallets (id),
+    to_wallet_id UUID NOT NULL REFERENCES wallets (id),
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (from_wallet_id <> to_wallet_id)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id UUID REFERENCES transfers (id);
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN IF EXISTS transfer_id;
+DROP TABLE IF EXISTS transfers;
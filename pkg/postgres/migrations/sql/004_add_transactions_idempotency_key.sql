@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS request_hash CHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_idempotency_key_idx
+    ON transactions (idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS transactions_idempotency_key_idx;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS request_hash,
+    DROP COLUMN IF EXISTS idempotency_key;
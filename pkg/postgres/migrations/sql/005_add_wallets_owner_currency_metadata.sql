@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS owner_ref VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS currency,
+    DROP COLUMN IF EXISTS owner_ref;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- The check is added NOT VALID so existing rows do not block the migration.
+-- It is validated here only when no wallet is negative; otherwise the
+-- offending wallets are reported and must be fixed before running
+-- ALTER TABLE wallets VALIDATE CONSTRAINT wallets_balance_non_negative.
+ALTER TABLE wallets
+    ADD CONSTRAINT wallets_balance_non_negative CHECK (balance >= 0) NOT VALID;
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+    offending BIGINT;
+    sample TEXT;
+BEGIN
+    SELECT count(*), string_agg(id::text || '=' || balance, ', ')
+    INTO offending, sample
+    FROM (SELECT id, balance FROM wallets WHERE balance < 0 ORDER BY id LIMIT 20) w;
+
+    IF offending = 0 THEN
+        ALTER TABLE wallets VALIDATE CONSTRAINT wallets_balance_non_negative;
+    ELSE
+        RAISE WARNING 'wallets_balance_non_negative left unvalidated, negative balances: %', sample;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_non_negative;
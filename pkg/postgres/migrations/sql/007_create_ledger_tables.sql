@@ -0,0 +1,103 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type VARCHAR(16) NOT NULL,
+    code VARCHAR(32),
+    wallet_id UUID UNIQUE REFERENCES wallets (id),
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (type IN ('WALLET', 'SYSTEM')),
+    CHECK ((type = 'WALLET') = (wallet_id IS NOT NULL)),
+    CHECK ((type = 'SYSTEM') = (code IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_system_code_currency_idx
+    ON accounts (code, currency)
+    WHERE type = 'SYSTEM';
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(32) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id UUID NOT NULL REFERENCES journal_entries (id),
+    account_id UUID NOT NULL REFERENCES accounts (id),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS postings_entry_id_idx ON postings (entry_id);
+CREATE INDEX IF NOT EXISTS postings_account_id_idx ON postings (account_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS journal_entry_id UUID REFERENCES journal_entries (id);
+
+-- Every journal entry must net to zero in each currency. The check is
+-- deferred to commit so that all postings of an entry can be inserted first.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM postings
+        WHERE entry_id = NEW.entry_id
+        GROUP BY currency
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW.entry_id
+            USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT OR UPDATE ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+INSERT INTO accounts (type, wallet_id, currency)
+SELECT 'WALLET', id, currency
+FROM wallets
+ON CONFLICT (wallet_id) DO NOTHING;
+
+INSERT INTO accounts (type, code, currency)
+SELECT 'SYSTEM', c.code, w.currency
+FROM (VALUES ('CASH_IN'), ('CASH_OUT'), ('FEES')) AS c (code)
+CROSS JOIN (SELECT currency FROM wallets UNION SELECT 'USD') AS w
+ON CONFLICT (code, currency) WHERE type = 'SYSTEM' DO NOTHING;
+
+-- Existing balances are brought onto the ledger as opening entries funded
+-- from the cash-in account of the wallet currency.
+-- +goose StatementBegin
+DO $$
+DECLARE
+    w RECORD;
+    entry UUID;
+BEGIN
+    FOR w IN SELECT id, balance, currency FROM wallets WHERE balance <> 0 LOOP
+        INSERT INTO journal_entries (kind) VALUES ('OPENING_BALANCE') RETURNING id INTO entry;
+
+        INSERT INTO postings (entry_id, account_id, amount, currency)
+        SELECT entry, id, w.balance, w.currency FROM accounts WHERE wallet_id = w.id;
+
+        INSERT INTO postings (entry_id, account_id, amount, currency)
+        SELECT entry, id, -w.balance, w.currency
+        FROM accounts
+        WHERE type = 'SYSTEM' AND code = 'CASH_IN' AND currency = w.currency;
+    END LOOP;
+END;
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS postings_balanced ON postings;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+ALTER TABLE transactions DROP COLUMN IF EXISTS journal_entry_id;
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS accounts;
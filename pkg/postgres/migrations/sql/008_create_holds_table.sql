@@ -0,0 +1,41 @@
+-- +goose Up
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS held BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT wallets_held_within_balance CHECK (held >= 0 AND held <= balance) NOT VALID;
+
+-- Wallets with a negative balance, reported by 006, would fail the check.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM wallets WHERE balance < 0) THEN
+        ALTER TABLE wallets VALIDATE CONSTRAINT wallets_held_within_balance;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets (id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'ACTIVE',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (status IN ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED')),
+    CHECK (captured_amount >= 0 AND captured_amount <= amount)
+);
+
+CREATE INDEX IF NOT EXISTS holds_wallet_id_idx ON holds (wallet_id);
+CREATE INDEX IF NOT EXISTS holds_active_expires_at_idx ON holds (expires_at) WHERE status = 'ACTIVE';
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS hold_id UUID REFERENCES holds (id);
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN IF EXISTS hold_id;
+DROP TABLE IF EXISTS holds;
+
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS wallets_held_within_balance,
+    DROP COLUMN IF EXISTS held;
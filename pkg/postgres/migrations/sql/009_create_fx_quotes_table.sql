@@ -0,0 +1,51 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    from_currency CHAR(3) NOT NULL,
+    to_currency CHAR(3) NOT NULL,
+    rate NUMERIC(30, 10) NOT NULL CHECK (rate > 0),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    converted_amount BIGINT NOT NULL CHECK (converted_amount > 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (from_currency <> to_currency)
+);
+
+ALTER TABLE transfers
+    ADD COLUMN IF NOT EXISTS from_currency CHAR(3),
+    ADD COLUMN IF NOT EXISTS to_currency CHAR(3),
+    ADD COLUMN IF NOT EXISTS to_amount BIGINT,
+    ADD COLUMN IF NOT EXISTS rate NUMERIC(30, 10),
+    ADD COLUMN IF NOT EXISTS quote_id UUID UNIQUE REFERENCES fx_quotes (id);
+
+UPDATE transfers t
+SET from_currency = f.currency, to_currency = d.currency, to_amount = t.amount
+FROM wallets f, wallets d
+WHERE f.id = t.from_wallet_id AND d.id = t.to_wallet_id;
+
+ALTER TABLE transfers
+    ALTER COLUMN from_currency SET NOT NULL,
+    ALTER COLUMN to_currency SET NOT NULL,
+    ALTER COLUMN to_amount SET NOT NULL;
+
+-- Cross-currency transfers post each leg against the FX account of its
+-- currency.
+INSERT INTO accounts (type, code, currency)
+SELECT DISTINCT 'SYSTEM', 'FX', currency
+FROM accounts
+ON CONFLICT (code, currency) WHERE type = 'SYSTEM' DO NOTHING;
+
+-- +goose Down
+DELETE FROM accounts a
+WHERE a.type = 'SYSTEM' AND a.code = 'FX'
+  AND NOT EXISTS (SELECT 1 FROM postings p WHERE p.account_id = a.id);
+
+ALTER TABLE transfers
+    DROP COLUMN IF EXISTS quote_id,
+    DROP COLUMN IF EXISTS rate,
+    DROP COLUMN IF EXISTS to_amount,
+    DROP COLUMN IF EXISTS to_currency,
+    DROP COLUMN IF EXISTS from_currency;
+
+DROP TABLE IF EXISTS fx_quotes;
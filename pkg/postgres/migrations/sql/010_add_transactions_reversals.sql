@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS status VARCHAR(24) NOT NULL DEFAULT 'COMPLETED',
+    ADD COLUMN IF NOT EXISTS reversed_amount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS original_transaction_id UUID REFERENCES transactions (id),
+    ADD CONSTRAINT transactions_status_check CHECK (status IN ('COMPLETED', 'PARTIALLY_REVERSED', 'REVERSED')),
+    ADD CONSTRAINT transactions_reversed_amount_check CHECK (reversed_amount >= 0 AND reversed_amount <= amount);
+
+CREATE INDEX IF NOT EXISTS transactions_original_transaction_id_idx
+    ON transactions (original_transaction_id)
+    WHERE original_transaction_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS transactions_original_transaction_id_idx;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_reversed_amount_check,
+    DROP CONSTRAINT IF EXISTS transactions_status_check,
+    DROP COLUMN IF EXISTS original_transaction_id,
+    DROP COLUMN IF EXISTS reversed_amount,
+    DROP COLUMN IF EXISTS status;
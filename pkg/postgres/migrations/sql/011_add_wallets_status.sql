@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'ACTIVE',
+    ADD COLUMN IF NOT EXISTS deposits_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT wallets_status_check CHECK (status IN ('ACTIVE', 'FROZEN', 'CLOSED'));
+
+CREATE TABLE IF NOT EXISTS wallet_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets (id),
+    from_status VARCHAR(16) NOT NULL,
+    to_status VARCHAR(16) NOT NULL,
+    deposits_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS wallet_status_history_wallet_id_idx
+    ON wallet_status_history (wallet_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS wallet_status_history;
+
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS wallets_status_check,
+    DROP COLUMN IF EXISTS deposits_blocked,
+    DROP COLUMN IF EXISTS status;
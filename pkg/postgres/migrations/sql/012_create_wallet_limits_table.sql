@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS tier VARCHAR(32) NOT NULL DEFAULT 'STANDARD';
+
+-- A row holds the limits of either a single wallet or a whole tier. NULL
+-- means the limit is not set. Wallet limits override the limits of its tier
+-- one by one.
+CREATE TABLE IF NOT EXISTS wallet_limits (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID UNIQUE REFERENCES wallets (id),
+    tier VARCHAR(32) UNIQUE,
+    max_single_amount BIGINT CHECK (max_single_amount > 0),
+    daily_withdrawal_cap BIGINT CHECK (daily_withdrawal_cap >= 0),
+    monthly_withdrawal_cap BIGINT CHECK (monthly_withdrawal_cap >= 0),
+    max_operations INTEGER CHECK (max_operations > 0),
+    operations_window_seconds INTEGER CHECK (operations_window_seconds > 0),
+    min_balance BIGINT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((wallet_id IS NULL) <> (tier IS NULL)),
+    CHECK ((max_operations IS NULL) = (operations_window_seconds IS NULL))
+);
+
+INSERT INTO wallet_limits (tier, max_single_amount, daily_withdrawal_cap, monthly_withdrawal_cap, max_operations, operations_window_seconds)
+VALUES ('UNVERIFIED', 100000, 100000, 500000, 20, 86400)
+ON CONFLICT (tier) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS wallet_limits;
+ALTER TABLE wallets DROP COLUMN IF EXISTS tier;
@@ -0,0 +1,49 @@
+-- +goose Up
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS credit_limit BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT wallets_credit_limit_non_negative CHECK (credit_limit >= 0),
+    DROP CONSTRAINT IF EXISTS wallets_balance_non_negative,
+    DROP CONSTRAINT IF EXISTS wallets_held_within_balance;
+
+-- Like wallets_balance_non_negative in 006, the checks are only validated
+-- when no existing wallet violates them.
+ALTER TABLE wallets
+    ADD CONSTRAINT wallets_balance_within_credit CHECK (balance >= -credit_limit) NOT VALID,
+    ADD CONSTRAINT wallets_held_within_balance CHECK (held >= 0 AND held <= balance + credit_limit) NOT VALID;
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+    offending BIGINT;
+    sample TEXT;
+BEGIN
+    SELECT count(*), string_agg(id::text || '=' || balance, ', ')
+    INTO offending, sample
+    FROM (
+        SELECT id, balance FROM wallets
+        WHERE balance < -credit_limit OR held < 0 OR held > balance + credit_limit
+        ORDER BY id LIMIT 20
+    ) w;
+
+    IF offending = 0 THEN
+        ALTER TABLE wallets VALIDATE CONSTRAINT wallets_balance_within_credit;
+        ALTER TABLE wallets VALIDATE CONSTRAINT wallets_held_within_balance;
+    ELSE
+        RAISE WARNING 'wallet balance checks left unvalidated, offending balances: %', sample;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS wallets_held_within_balance,
+    DROP CONSTRAINT IF EXISTS wallets_balance_within_credit;
+
+-- Wallets drawing on credit would violate the old check, so it comes back
+-- NOT VALID.
+ALTER TABLE wallets
+    ADD CONSTRAINT wallets_balance_non_negative CHECK (balance >= 0) NOT VALID,
+    ADD CONSTRAINT wallets_held_within_balance CHECK (held >= 0 AND held <= balance) NOT VALID,
+    DROP CONSTRAINT IF EXISTS wallets_credit_limit_non_negative,
+    DROP COLUMN IF EXISTS credit_limit;
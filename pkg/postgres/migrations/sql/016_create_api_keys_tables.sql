@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(128) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    rotated_from UUID REFERENCES api_keys (id),
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    id BIGSERIAL PRIMARY KEY,
+    key_id UUID NOT NULL REFERENCES api_keys (id),
+    method VARCHAR(8) NOT NULL,
+    route TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    client_ip TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS api_key_usage_key_id_idx ON api_key_usage (key_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS wallet_owners (
+    wallet_id UUID NOT NULL REFERENCES wallets (id),
+    subject VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wallet_id, subject)
+);
+
+CREATE INDEX IF NOT EXISTS wallet_owners_subject_idx ON wallet_owners (subject);
+
+-- +goose Down
+DROP TABLE IF EXISTS wallet_owners;
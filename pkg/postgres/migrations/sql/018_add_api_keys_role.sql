@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS role VARCHAR(16)
+        CHECK (role IN ('support', 'finance', 'admin'));
+
+-- Existing keys get the role that matches the scopes they were issued with,
+-- so admin keys keep full access and transact keys can still move money.
+-- Read-only keys get the least privileged role. New keys always name their role.
+UPDATE api_keys
+SET role = CASE
+    WHEN 'admin' = ANY(scopes) THEN 'admin'
+    WHEN 'transact' = ANY(scopes) THEN 'finance'
+    ELSE 'support'
+END
+WHERE role IS NULL;
+
+ALTER TABLE api_keys ALTER COLUMN role SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE api_keys DROP COLUMN IF EXISTS role;
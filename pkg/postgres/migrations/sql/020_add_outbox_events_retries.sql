@@ -0,0 +1,24 @@
+-- +goose Up
+-- Failed events are retried at next_attempt_at and dead-lettered by setting
+-- dead_at once they run out of attempts.
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS dead_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS outbox_events_unpublished_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx
+    ON outbox_events (next_attempt_at) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_events_dead_idx
+    ON outbox_events (dead_at) WHERE dead_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS outbox_events_dead_idx;
+DROP INDEX IF EXISTS outbox_events_pending_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx
+    ON outbox_events (id) WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events
+    DROP COLUMN IF EXISTS dead_at,
+    DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/SerzhLimon/PaymentService/internal/metrics"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/transport"
+)
+
+func scrapeMetrics(t *testing.T, handler http.Handler) string {
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	return string(body)
+}
+
+func Test_Router_Metrics(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server})
+
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "").
+		Return(models.APIKey{}, fmt.Errorf("usecase.AuthenticateAPIKey: %w", models.ErrUnauthorized))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets/"+activeWallet.ID+"/limits", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	body := scrapeMetrics(t, metrics.Handler())
+	assert.Contains(t, body, `payments_http_requests_total{method="GET",route="GetWalletLimits",status="401"}`)
+	assert.Contains(t, body, `payments_http_request_duration_seconds_count{method="GET",route="GetWalletLimits"}`)
+	assert.Contains(t, body, "go_goroutines")
+
+	// Metrics are served on their own listener, never by the public API.
+	req, _ = http.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_WalletTransaction_Metrics(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	err := fmt.Errorf("pgRepo.WalletTransactionWithdraw %w", &models.InsufficientFundsError{Available: 300})
+	mockUsecase.On("WalletTransaction", mock.Anything, mock.Anything).Return(models.Transaction{}, err).Once()
+	mockUsecase.On("WalletTransaction", mock.Anything, mock.Anything).Return(models.Transaction{ID: "t1", Currency: "JPY"}, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		body := fmt.Sprintf(`{"wallet_id": %q, "operation": "WITHDRAW", "amount": 700}`, activeWallet.ID)
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	scraped := scrapeMetrics(t, metrics.Handler())
+	assert.Contains(t, scraped, `payments_transactions_total{operation="WITHDRAW",outcome="insufficient_funds"}`)
+	assert.Contains(t, scraped, `payments_transactions_total{operation="WITHDRAW",outcome="success"}`)
+	assert.Contains(t, scraped, `payments_transaction_amount_total{currency="JPY",operation="WITHDRAW",outcome="success"} 700`)
+	assert.NotContains(t, scraped, `payments_transaction_amount_total{currency="",`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_ReverseTransaction_Metrics_Refund(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	refund := models.Transaction{ID: "t2", Operation: models.OperationRefund, Amount: 250, Currency: "EUR"}
+	mockUsecase.On("ReverseTransaction", mock.Anything, mock.Anything).Return(models.Reversal{Reversal: refund}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/transactions/"+activeWallet.ID+"/reverse", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	scraped := scrapeMetrics(t, metrics.Handler())
+	assert.Contains(t, scraped, `payments_transactions_total{operation="REFUND",outcome="success"}`)
+	assert.Contains(t, scraped, `payments_transaction_amount_total{currency="EUR",operation="REFUND",outcome="success"} 250`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestMetrics_UnknownOperationLabel(t *testing.T) {
+	metrics.ObserveTransaction("DROP TABLE wallets", "DROP", 1, models.ErrUnknownOperation)
+
+	scraped := scrapeMetrics(t, metrics.Handler())
+	assert.Contains(t, scraped, `payments_transactions_total{operation="UNKNOWN",outcome="unknown_operation"}`)
+	assert.NotContains(t, scraped, "DROP TABLE")
+}
+
+func TestMetrics_OutboxLag(t *testing.T) {
+	metrics.RegisterOutboxLag(func(context.Context) (models.OutboxLag, error) {
+		return models.OutboxLag{Pending: 3, OldestAge: 90 * time.Second, Dead: 1}, nil
+	})
+
+	scraped := scrapeMetrics(t, metrics.Handler())
+	assert.Contains(t, scraped, "payments_outbox_pending_events 3")
+	assert.Contains(t, scraped, "payments_outbox_oldest_pending_age_seconds 90")
+	assert.Contains(t, scraped, "payments_outbox_dead_events 1")
+}
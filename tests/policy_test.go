@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/SerzhLimon/PaymentService/internal/auth"
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/policy"
+	"github.com/SerzhLimon/PaymentService/internal/transport"
+	"github.com/SerzhLimon/PaymentService/internal/usecase"
+)
+
+func TestDefaultRules_CoverEveryMethod(t *testing.T) {
+	methods := reflect.TypeOf((*usecase.UseCase)(nil)).Elem()
+	for i := 0; i < methods.NumMethod(); i++ {
+		name := methods.Method(i).Name
+		assert.NotEmpty(t, policy.DefaultRules[name], "no rule for %s", name)
+	}
+	for name := range policy.DefaultRules {
+		_, ok := methods.MethodByName(name)
+		assert.True(t, ok, "rule for unknown method %s", name)
+	}
+}
+
+func TestDefaultRules_Allowed(t *testing.T) {
+	cases := []struct {
+		role    string
+		method  string
+		allowed bool
+	}{
+		{models.RoleCustomer, "GetBalance", true},
+		{models.RoleCustomer, "WalletTransaction", true},
+		{models.RoleCustomer, "CreateWallet", false},
+		{models.RoleCustomer, "FreezeWallet", false},
+		{models.RoleCustomer, "ReverseTransaction", false},
+		{models.RoleSupport, "CreateWallet", true},
+		{models.RoleSupport, "FreezeWallet", true},
+		{models.RoleSupport, "GetTransactions", true},
+		{models.RoleSupport, "WalletTransaction", false},
+		{models.RoleSupport, "ReverseTransaction", false},
+		{models.RoleSupport, "CloseWallet", false},
+		{models.RoleSupport, "CreateAPIKey", false},
+		{models.RoleFinance, "ReverseTransaction", true},
+		{models.RoleFinance, "SetCreditLimit", true},
+		{models.RoleFinance, "FreezeWallet", false},
+		{models.RoleFinance, "CreateHold", true},
+		{models.RoleFinance, "CaptureHold", true},
+		{models.RoleFinance, "CreateWebhook", true},
+		{models.RoleFinance, "GetWebhooks", true},
+		{models.RoleFinance, "DeleteWebhook", false},
+		{models.RoleSupport, "CreateHold", false},
+		{models.RoleSupport, "VoidHold", true},
+		{models.RoleAdmin, "CloseWallet", true},
+		{models.RoleAdmin, "CreateAPIKey", true},
+		{"", "GetBalance", false},
+		{"", "ExpireHolds", true},
+		{"", "AuthenticateAPIKey", true},
+		{models.RoleSupport, "NoSuchMethod", false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.allowed, policy.DefaultRules.Allowed(tc.role, tc.method), "%s calling %s", tc.role, tc.method)
+	}
+}
+
+func TestPolicy_DelegatesAllowedCall(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	uc := policy.NewUsecase(mockUsecase, policy.DefaultRules)
+
+	request := models.WalletStatusRequest{WalletID: activeWallet.ID, Reason: "suspected fraud"}
+	ctx := auth.WithRole(context.Background(), models.RoleSupport)
+	mockUsecase.On("FreezeWallet", ctx, request).Return(models.Wallet{ID: activeWallet.ID, Status: models.WalletStatusFrozen}, nil)
+
+	res, err := uc.FreezeWallet(ctx, request)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WalletStatusFrozen, res.Status)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPolicy_RejectsForbiddenCall(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	uc := policy.NewUsecase(mockUsecase, policy.DefaultRules)
+
+	ctx := auth.WithRole(context.Background(), models.RoleSupport)
+	_, err := uc.ReverseTransaction(ctx, models.ReverseTransactionRequest{TransactionID: activeWallet.ID})
+	assert.ErrorIs(t, err, models.ErrForbidden)
+
+	_, err = uc.GetBalance(context.Background(), activeWallet.ID)
+	assert.ErrorIs(t, err, models.ErrForbidden)
+
+	mockUsecase.AssertNotCalled(t, "ReverseTransaction", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything)
+}
+
+func Test_Router_SupportRole(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: policy.NewUsecase(mockUsecase, policy.DefaultRules)}
+	router := transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server})
+
+	agent := models.APIKey{ID: "k1", Scopes: []string{models.ScopeAdmin}, Role: models.RoleSupport}
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "wk_support").Return(agent, nil)
+	mockUsecase.On("RecordAPIKeyUsage", mock.Anything, mock.Anything).Return(nil)
+	mockUsecase.On("FreezeWallet", mock.Anything, mock.Anything).Return(models.Wallet{ID: activeWallet.ID, Status: models.WalletStatusFrozen}, nil)
+
+	freeze, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/wallets/"+activeWallet.ID+"/freeze", bytes.NewBufferString(`{"reason": "suspected fraud"}`))
+	freeze.Header.Set("X-API-Key", "wk_support")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, freeze)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	reverse, _ := http.NewRequest(http.MethodPost, "/api/v1/transactions/"+activeWallet.ID+"/reverse", nil)
+	reverse.Header.Set("X-API-Key", "wk_support")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, reverse)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"forbidden"`)
+
+	mockUsecase.AssertNotCalled(t, "ReverseTransaction", mock.Anything, mock.Anything)
+}
+
+func Test_Router_FinanceWebhooks(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: policy.NewUsecase(mockUsecase, policy.DefaultRules)}
+	router := transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server})
+
+	partner := models.APIKey{ID: "k1", Scopes: []string{models.ScopeBalanceRead, models.ScopeTransact}, Role: models.RoleFinance}
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "wk_partner").Return(partner, nil)
+	mockUsecase.On("RecordAPIKeyUsage", mock.Anything, mock.Anything).Return(nil)
+	request := models.CreateWebhookRequest{URL: "https://partner.example.com/hooks", EventTypes: []string{models.WebhookEventDeposit}}
+	mockUsecase.On("CreateWebhook", mock.Anything, request).Return(models.WebhookEndpoint{ID: "w1", URL: request.URL}, nil)
+	mockUsecase.On("GetWebhooks", mock.Anything).Return([]models.WebhookEndpoint{{ID: "w1", URL: request.URL}}, nil)
+
+	create, _ := http.NewRequest(http.MethodPost, "/api/v1/webhooks",
+		bytes.NewBufferString(`{"url": "https://partner.example.com/hooks", "event_types": ["wallet.deposit"]}`))
+	create.Header.Set("X-API-Key", "wk_partner")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, create)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	list, _ := http.NewRequest(http.MethodGet, "/api/v1/webhooks", nil)
+	list.Header.Set("X-API-Key", "wk_partner")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, list)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"w1"`)
+
+	remove, _ := http.NewRequest(http.MethodDelete, "/api/v1/webhooks/w1", nil)
+	remove.Header.Set("X-API-Key", "wk_partner")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, remove)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	mockUsecase.AssertExpectations(t)
+	mockUsecase.AssertNotCalled(t, "DeleteWebhook", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/tracing"
+	"github.com/SerzhLimon/PaymentService/internal/transport"
+)
+
+const (
+	incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	incomingSpanID  = "00f067aa0ba902b7"
+)
+
+// tracedRouter routes requests to a traced mockUsecase and records the spans
+// they produce.
+func tracedRouter(t *testing.T, mockUsecase *MockUsecase) (*gin.Engine, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	server := &transport.Server{Usecase: tracing.NewUsecase(mockUsecase)}
+	return transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server}), recorder
+}
+
+func spanNamed(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func Test_Tracing_ContinuesIncomingTrace(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	router, recorder := tracedRouter(t, mockUsecase)
+
+	reader := models.APIKey{ID: "k1", Scopes: []string{models.ScopeBalanceRead}, Role: models.RoleAdmin}
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "wk_reader").Return(reader, nil)
+	mockUsecase.On("RecordAPIKeyUsage", mock.Anything, mock.Anything).Return(nil)
+	mockUsecase.On("GetBalance", mock.Anything, activeWallet.ID).Return(models.GetBalanceResponse{Currency: "USD"}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id="+activeWallet.ID, nil)
+	req.Header.Set("X-API-Key", "wk_reader")
+	req.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-01", incomingTraceID, incomingSpanID))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	spans := recorder.Ended()
+	server := spanNamed(spans, "GET /api/v1/wallets")
+	if assert.NotNil(t, server) {
+		assert.Equal(t, incomingTraceID, server.SpanContext().TraceID().String())
+		assert.Equal(t, incomingSpanID, server.Parent().SpanID().String())
+	}
+	for _, name := range []string{"usecase.AuthenticateAPIKey", "usecase.GetBalance"} {
+		span := spanNamed(spans, name)
+		if assert.NotNil(t, span, name) && server != nil {
+			assert.Equal(t, server.SpanContext().SpanID(), span.Parent().SpanID(), name)
+		}
+	}
+}
+
+func Test_Tracing_RecordsUsecaseError(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	router, recorder := tracedRouter(t, mockUsecase)
+
+	key := models.APIKey{ID: "k1", Scopes: []string{models.ScopeBalanceRead}, Role: models.RoleAdmin}
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "wk_reader").Return(key, nil)
+	mockUsecase.On("RecordAPIKeyUsage", mock.Anything, mock.Anything).Return(nil)
+	mockUsecase.On("GetBalance", mock.Anything, activeWallet.ID).
+		Return(models.GetBalanceResponse{}, fmt.Errorf("pgRepo.GetBalance: %w", models.ErrWalletNotFound))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id="+activeWallet.ID, nil)
+	req.Header.Set("X-API-Key", "wk_reader")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := spanNamed(recorder.Ended(), "usecase.GetBalance")
+	if assert.NotNil(t, span) {
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Contains(t, span.Status().Description, "wallet not found")
+	}
+}
@@ -2,43 +2,296 @@ package tests
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/SerzhLimon/PaymentService/internal/auth"
 	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/outbox"
 	"github.com/SerzhLimon/PaymentService/internal/transport"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
 )
 
 type MockUsecase struct {
 	mock.Mock
 }
 
-func (m *MockUsecase) WalletTransaction(req models.WalletTransaction) error {
-	args := m.Called(req)
-	return args.Error(0)
+func (m *MockUsecase) WalletTransaction(ctx context.Context, req models.WalletTransaction) (models.Transaction, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Transaction), args.Error(1)
+}
+
+func (m *MockUsecase) Transfer(ctx context.Context, req models.TransferRequest) (models.Transfer, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Transfer), args.Error(1)
 }
 
-func (m *MockUsecase) GetBalance(walletID string) (models.GetBalanceResponse, error) {
-	args := m.Called(walletID)
+func (m *MockUsecase) GetBalance(ctx context.Context, walletID string) (models.GetBalanceResponse, error) {
+	args := m.Called(ctx, walletID)
 	return args.Get(0).(models.GetBalanceResponse), args.Error(1)
 }
 
-func (m *MockUsecase) CreateWallet() error {
-	return nil
+func (m *MockUsecase) GetTransactions(ctx context.Context, req models.GetTransactionsRequest) (models.TransactionList, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.TransactionList), args.Error(1)
+}
+
+func (m *MockUsecase) CreateWallet(ctx context.Context, req models.CreateWalletRequest) (models.Wallet, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockUsecase) ReconcileWallet(ctx context.Context, walletID string) (models.Reconciliation, error) {
+	args := m.Called(ctx, walletID)
+	return args.Get(0).(models.Reconciliation), args.Error(1)
+}
+
+func (m *MockUsecase) GetTrialBalance(ctx context.Context) (models.TrialBalance, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(models.TrialBalance), args.Error(1)
+}
+
+func (m *MockUsecase) CreateQuote(ctx context.Context, req models.CreateQuoteRequest) (models.Quote, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+func (m *MockUsecase) GetQuote(ctx context.Context, id string) (models.Quote, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+func (m *MockUsecase) ReverseTransaction(ctx context.Context, req models.ReverseTransactionRequest) (models.Reversal, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Reversal), args.Error(1)
+}
+
+func (m *MockUsecase) FreezeWallet(ctx context.Context, req models.WalletStatusRequest) (models.Wallet, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockUsecase) UnfreezeWallet(ctx context.Context, req models.WalletStatusRequest) (models.Wallet, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockUsecase) CloseWallet(ctx context.Context, req models.WalletStatusRequest) (models.Wallet, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockUsecase) GetWalletStatusHistory(ctx context.Context, id string) ([]models.WalletStatusChange, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]models.WalletStatusChange), args.Error(1)
+}
+
+func (m *MockUsecase) GetWalletLimits(ctx context.Context, id string) (models.Limits, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Limits), args.Error(1)
+}
+
+func (m *MockUsecase) RelayOutbox(ctx context.Context, publisher outbox.Publisher, policy webhook.RetryPolicy, limit int) (int, error) {
+	args := m.Called(ctx, publisher, policy, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUsecase) CreateWebhook(ctx context.Context, req models.CreateWebhookRequest) (models.WebhookEndpoint, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockUsecase) GetWebhooks(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockUsecase) GetWebhook(ctx context.Context, id string) (models.WebhookEndpoint, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockUsecase) DeleteWebhook(ctx context.Context, id string) (models.WebhookEndpoint, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockUsecase) GetWebhookDeliveries(ctx context.Context, req models.GetWebhookDeliveriesRequest) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockUsecase) GetWebhookDelivery(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockUsecase) ReplayWebhookDelivery(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockUsecase) DeliverWebhooks(ctx context.Context, sender webhook.Sender, policy webhook.RetryPolicy, limit int) (int, error) {
+	args := m.Called(ctx, sender, policy, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUsecase) CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (models.APIKey, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockUsecase) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockUsecase) RotateAPIKey(ctx context.Context, req models.RotateAPIKeyRequest) (models.APIKey, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockUsecase) RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockUsecase) GetAPIKeyUsage(ctx context.Context, req models.GetAPIKeyUsageRequest) ([]models.APIKeyUsage, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]models.APIKeyUsage), args.Error(1)
+}
+
+func (m *MockUsecase) AuthenticateAPIKey(ctx context.Context, key string) (models.APIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockUsecase) RecordAPIKeyUsage(ctx context.Context, usage models.APIKeyUsage) error {
+	args := m.Called(ctx, usage)
+	return args.Error(0)
+}
+
+func (m *MockUsecase) BootstrapAPIKey(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockUsecase) SetCreditLimit(ctx context.Context, req models.SetCreditLimitRequest) (models.Wallet, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockUsecase) GetOutboxLag(ctx context.Context) (models.OutboxLag, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(models.OutboxLag), args.Error(1)
+}
+
+func (m *MockUsecase) GetWalletOwners(ctx context.Context, id string) ([]models.WalletOwner, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]models.WalletOwner), args.Error(1)
+}
+
+func (m *MockUsecase) AddWalletOwner(ctx context.Context, req models.WalletOwnerRequest) (models.WalletOwner, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.WalletOwner), args.Error(1)
+}
+
+func (m *MockUsecase) RemoveWalletOwner(ctx context.Context, req models.WalletOwnerRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUsecase) SetWalletLimits(ctx context.Context, req models.SetLimitsRequest) (models.Limits, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Limits), args.Error(1)
+}
+
+func (m *MockUsecase) SetTierLimits(ctx context.Context, req models.SetLimitsRequest) (models.Limits, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Limits), args.Error(1)
+}
+
+func (m *MockUsecase) SetWalletTier(ctx context.Context, req models.SetTierRequest) (models.Wallet, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockUsecase) CreateHold(ctx context.Context, req models.CreateHoldRequest) (models.Hold, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Hold), args.Error(1)
+}
+
+func (m *MockUsecase) GetHold(ctx context.Context, id string) (models.Hold, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Hold), args.Error(1)
+}
+
+func (m *MockUsecase) CaptureHold(ctx context.Context, req models.CaptureHoldRequest) (models.CaptureHoldResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.CaptureHoldResponse), args.Error(1)
+}
+
+func (m *MockUsecase) VoidHold(ctx context.Context, id string) (models.Hold, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Hold), args.Error(1)
+}
+
+func (m *MockUsecase) ExpireHolds(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func setupRouter(s *transport.Server) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
 	r.POST("/api/v1/wallet", s.WalletTransaction)
+	r.POST("/api/v1/transfers", s.Transfer)
 	r.GET("/api/v1/wallets", s.GetBalance)
+	r.POST("/api/v1/wallets", s.CreateWallet)
+	r.GET("/api/v1/wallets/:id/reconciliation", s.ReconcileWallet)
+	r.GET("/api/v1/ledger/trial-balance", s.GetTrialBalance)
+	r.GET("/api/v1/wallets/:id/transactions", s.GetTransactions)
+	r.POST("/api/v1/wallets/:id/holds", s.CreateHold)
+	r.GET("/api/v1/holds/:id", s.GetHold)
+	r.POST("/api/v1/holds/:id/capture", s.CaptureHold)
+	r.POST("/api/v1/holds/:id/void", s.VoidHold)
+	r.POST("/api/v1/transactions/:id/reverse", s.ReverseTransaction)
+	r.POST("/api/v1/admin/wallets/:id/freeze", s.FreezeWallet)
+	r.POST("/api/v1/admin/wallets/:id/unfreeze", s.UnfreezeWallet)
+	r.POST("/api/v1/admin/wallets/:id/close", s.CloseWallet)
+	r.GET("/api/v1/admin/wallets/:id/status-history", s.GetWalletStatusHistory)
+	r.GET("/api/v1/wallets/:id/limits", s.GetWalletLimits)
+	r.PUT("/api/v1/admin/wallets/:id/limits", s.SetWalletLimits)
+	r.PUT("/api/v1/admin/wallets/:id/tier", s.SetWalletTier)
+	r.PUT("/api/v1/admin/tiers/:tier/limits", s.SetTierLimits)
+	r.PUT("/api/v1/admin/wallets/:id/credit-limit", s.SetCreditLimit)
+	r.GET("/api/v1/admin/wallets/:id/owners", s.GetWalletOwners)
+	r.PUT("/api/v1/admin/wallets/:id/owners/:subject", s.AddWalletOwner)
+	r.DELETE("/api/v1/admin/wallets/:id/owners/:subject", s.RemoveWalletOwner)
+	r.POST("/api/v1/webhooks", s.CreateWebhook)
+	r.POST("/api/v1/admin/api-keys", s.CreateAPIKey)
+	r.POST("/api/v1/admin/api-keys/:id/rotate", s.RotateAPIKey)
+	r.GET("/api/v1/webhook-deliveries", s.GetWebhookDeliveries)
+	r.POST("/api/v1/webhook-deliveries/:id/replay", s.ReplayWebhookDelivery)
+	r.POST("/api/v1/fx/quotes", s.CreateQuote)
+	r.GET("/api/v1/fx/quotes/:id", s.GetQuote)
 	return r
 }
 
@@ -53,7 +306,17 @@ func TestWalletTransaction_Success(t *testing.T) {
 		Amount:    100,
 	}
 
-	mockUsecase.On("WalletTransaction", requestBody).Return(nil)
+	mockResult := models.Transaction{
+		ID:        "0b8a3c55-4b53-4b0e-9a43-6f0f2f6a1d10",
+		WalletID:  requestBody.WalletID,
+		Operation: requestBody.Operation,
+		Amount:    requestBody.Amount,
+		Currency:  "USD",
+		Balance:   1100,
+		Status:    models.TransactionStatusCompleted,
+		CreatedAt: time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC),
+	}
+	mockUsecase.On("WalletTransaction", mock.Anything, requestBody).Return(mockResult, nil)
 
 	body, _ := json.Marshal(requestBody)
 	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
@@ -63,6 +326,17 @@ func TestWalletTransaction_Success(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"transaction_id": "0b8a3c55-4b53-4b0e-9a43-6f0f2f6a1d10",
+		"wallet_id": "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		"operation": "DEPOSIT",
+		"amount": 100,
+		"currency": "USD",
+		"balance": 1100,
+		"status": "COMPLETED",
+		"reversed_amount": 0,
+		"created_at": "2024-11-20T12:00:00Z"
+	}`, w.Body.String())
 	mockUsecase.AssertExpectations(t)
 }
 
@@ -79,7 +353,7 @@ func TestWalletTransaction_BindError(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "invalid JSON format")
-	mockUsecase.AssertNotCalled(t, "WalletTransaction")
+	mockUsecase.AssertNotCalled(t, "WalletTransaction", mock.Anything, mock.Anything)
 }
 
 func TestWalletTransaction_Failure(t *testing.T) {
@@ -93,7 +367,7 @@ func TestWalletTransaction_Failure(t *testing.T) {
 		Amount:    100,
 	}
 
-	mockUsecase.On("WalletTransaction", requestBody).Return(errors.New("transaction failed"))
+	mockUsecase.On("WalletTransaction", mock.Anything, requestBody).Return(models.Transaction{}, errors.New("transaction failed"))
 
 	body, _ := json.Marshal(requestBody)
 	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
@@ -102,18 +376,144 @@ func TestWalletTransaction_Failure(t *testing.T) {
 
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "transaction failed")
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestWalletTransaction_IdempotencyConflict(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	requestBody := models.WalletTransaction{
+		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Operation: "DEPOSIT",
+		Amount:    100,
+	}
+	expected := requestBody
+	expected.IdempotencyKey = "retry-1"
+
+	mockUsecase.On("WalletTransaction", mock.Anything, expected).Return(models.Transaction{}, models.ErrIdempotencyConflict)
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "retry-1")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"idempotency_conflict"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestWalletTransaction_IdempotentReplay(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	requestBody := models.WalletTransaction{
+		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Operation: "DEPOSIT",
+		Amount:    100,
+	}
+	expected := requestBody
+	expected.IdempotencyKey = "retry-1"
+
+	mockResult := models.Transaction{ID: "0b8a3c55-4b53-4b0e-9a43-6f0f2f6a1d10", Replayed: true}
+	mockUsecase.On("WalletTransaction", mock.Anything, expected).Return(mockResult, nil)
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "retry-1")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Contains(t, w.Body.String(), "0b8a3c55-4b53-4b0e-9a43-6f0f2f6a1d10")
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestWalletTransaction_ErrorMapping(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("usecase.WalletTransaction: %w", models.ErrInvalidAmount), http.StatusBadRequest, "invalid_amount"},
+		{fmt.Errorf("usecase.WalletTransaction: %w", models.ErrUnknownOperation), http.StatusBadRequest, "unknown_operation"},
+		{fmt.Errorf("pgRepo.WalletTransactionDeposit: %w", models.ErrWalletNotFound), http.StatusNotFound, "wallet_not_found"},
+		{errors.New("connection reset by peer"), http.StatusInternalServerError, "internal"},
+	}
+
+	requestBody := models.WalletTransaction{
+		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Operation: "DEPOSIT",
+		Amount:    100,
+	}
+	body, _ := json.Marshal(requestBody)
+
+	for _, tc := range cases {
+		mockUsecase := new(MockUsecase)
+		server := &transport.Server{Usecase: mockUsecase}
+		router := setupRouter(server)
+
+		mockUsecase.On("WalletTransaction", mock.Anything, requestBody).Return(models.Transaction{}, tc.err)
+
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.err.Error())
+		assert.Contains(t, w.Body.String(), `"code":"`+tc.code+`"`)
+		assert.NotContains(t, w.Body.String(), "connection reset")
+	}
+}
+
+func TestWalletTransaction_InsufficientFunds(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	requestBody := models.WalletTransaction{
+		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Operation: "WITHDRAW",
+		Amount:    1000,
+	}
+
+	err := fmt.Errorf("pgRepo.WalletTransactionWithdraw %w", &models.InsufficientFundsError{Available: 300})
+	mockUsecase.On("WalletTransaction", mock.Anything, requestBody).Return(models.Transaction{}, err)
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{
+		"code": "insufficient_funds",
+		"error": "insufficient funds",
+		"details": {"available_balance": 300}
+	}`, w.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
 func Test_GetBalance_Success(t *testing.T) {
 	mockUsecase := new(MockUsecase)
 	server := &transport.Server{Usecase: mockUsecase}
 	router := setupRouter(server)
 
-	mockResult := models.GetBalanceResponse{Amount: 100.0}
-	mockUsecase.On("GetBalance", "7b7ad84a-cb3e-4734-8e80-98aef40122d2").Return(mockResult, nil)
+	mockResult := models.GetBalanceResponse{Currency: "EUR", Ledger: 10000, Available: 6000, LedgerFormatted: "100.00", AvailableFormatted: "60.00"}
+	mockUsecase.On("GetBalance", mock.Anything, "7b7ad84a-cb3e-4734-8e80-98aef40122d2").Return(mockResult, nil)
 
 	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id=7b7ad84a-cb3e-4734-8e80-98aef40122d2", nil)
 	w := httptest.NewRecorder()
@@ -121,7 +521,7 @@ func Test_GetBalance_Success(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.JSONEq(t, `{"balance": 100.0}`, w.Body.String())
+	assert.JSONEq(t, `{"currency": "EUR", "ledger": 10000, "available": 6000, "credit_limit": 0, "credit_used": 0, "ledger_formatted": "100.00", "available_formatted": "60.00"}`, w.Body.String())
 	mockUsecase.AssertExpectations(t)
 }
 
@@ -137,7 +537,7 @@ func TestGetBalance_EmptyID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "parameter 'id' is empty")
-	mockUsecase.AssertNotCalled(t, "GetBalance")
+	mockUsecase.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything)
 }
 
 func Test_GetBalance_Failure(t *testing.T) {
@@ -145,14 +545,929 @@ func Test_GetBalance_Failure(t *testing.T) {
 	server := &transport.Server{Usecase: mockUsecase}
 	router := setupRouter(server)
 
-	mockUsecase.On("GetBalance", "123").Return(models.GetBalanceResponse{}, errors.New("failed to get balance"))
+	mockUsecase.On("GetBalance", mock.Anything, "123").Return(models.GetBalanceResponse{}, errors.New("failed to get balance"))
 
 	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id=123", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "failed to get balance")
 	mockUsecase.AssertExpectations(t)
 }
+
+func TestGetTransactions_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	minAmount := int64(50)
+	expected := models.GetTransactionsRequest{
+		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Operation: "DEPOSIT",
+		MinAmount: &minAmount,
+		Limit:     10,
+	}
+	mockResult := models.TransactionList{
+		Transactions: []models.Transaction{{
+			ID:        "0b8a3c55-4b53-4b0e-9a43-6f0f2f6a1d10",
+			WalletID:  expected.WalletID,
+			Operation: "DEPOSIT",
+			Amount:    100,
+			Balance:   100,
+			CreatedAt: time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC),
+		}},
+		NextCursor: "next",
+	}
+	mockUsecase.On("GetTransactions", mock.Anything, expected).Return(mockResult, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets/7b7ad84a-cb3e-4734-8e80-98aef40122d2/transactions?operation=DEPOSIT&min_amount=50&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"next_cursor":"next"`)
+	assert.Contains(t, w.Body.String(), `"transaction_id":"0b8a3c55-4b53-4b0e-9a43-6f0f2f6a1d10"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestGetTransactions_BindError(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets/7b7ad84a-cb3e-4734-8e80-98aef40122d2/transactions?limit=abc", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid query parameters")
+	mockUsecase.AssertNotCalled(t, "GetTransactions", mock.Anything, mock.Anything)
+}
+
+func Test_Transfer_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	requestBody := models.TransferRequest{
+		FromWalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		ToWalletID:   "c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34",
+		Amount:       250,
+	}
+	mockResult := models.Transfer{
+		ID:           "5e2d8f13-7a4c-4b9e-a1d6-3c8f0b2e4a57",
+		FromWalletID: requestBody.FromWalletID,
+		ToWalletID:   requestBody.ToWalletID,
+		Amount:       requestBody.Amount,
+	}
+	mockUsecase.On("Transfer", mock.Anything, requestBody).Return(mockResult, nil)
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"transfer_id":"5e2d8f13-7a4c-4b9e-a1d6-3c8f0b2e4a57"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_Transfer_Failure(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	requestBody := models.TransferRequest{
+		FromWalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		ToWalletID:   "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Amount:       250,
+	}
+	mockUsecase.On("Transfer", mock.Anything, requestBody).Return(models.Transfer{}, errors.New("transfer failed"))
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "transfer failed")
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CreateWallet_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	requestBody := models.CreateWalletRequest{
+		OwnerRef: "customer-42",
+		Currency: "EUR",
+		Metadata: json.RawMessage(`{"tier":"gold"}`),
+	}
+	mockResult := models.Wallet{
+		ID:       "c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34",
+		OwnerRef: &requestBody.OwnerRef,
+		Currency: "EUR",
+		Metadata: requestBody.Metadata,
+	}
+	mockUsecase.On("CreateWallet", mock.Anything, requestBody).Return(mockResult, nil)
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallets", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CreateWallet_EmptyBody(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	mockUsecase.On("CreateWallet", mock.Anything, models.CreateWalletRequest{}).Return(models.Wallet{ID: "c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34"}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallets", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CreateWallet_Conflict(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	requestBody := models.CreateWalletRequest{ID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2"}
+	mockUsecase.On("CreateWallet", mock.Anything, requestBody).Return(models.Wallet{}, models.ErrWalletExists)
+
+	body := []byte(`{"id": "7b7ad84a-cb3e-4734-8e80-98aef40122d2"}`)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallets", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestRequestTimeout_PropagatesDeadline(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(transport.RequestTimeout(time.Second))
+	router.GET("/api/v1/wallets", server.GetBalance)
+
+	hasDeadline := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+	mockUsecase.On("GetBalance", hasDeadline, "7b7ad84a-cb3e-4734-8e80-98aef40122d2").
+		Return(models.GetBalanceResponse{}, fmt.Errorf("pgRepo.GetBalance: %w", context.DeadlineExceeded))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id=7b7ad84a-cb3e-4734-8e80-98aef40122d2", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"timeout"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_ReconcileWallet_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	mockResult := models.Reconciliation{
+		WalletID:      walletID,
+		Balance:       500,
+		LedgerBalance: 500,
+		Balanced:      true,
+	}
+	mockUsecase.On("ReconcileWallet", mock.Anything, walletID).Return(mockResult, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID+"/reconciliation", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"wallet_id": "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		"balance": 500,
+		"ledger_balance": 500,
+		"difference": 0,
+		"balanced": true
+	}`, w.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_GetTrialBalance_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	mockResult := models.TrialBalance{
+		Lines: []models.TrialBalanceLine{
+			{Account: "CASH_IN", Currency: "USD", Balance: -1000},
+			{Account: "CASH_OUT", Currency: "USD", Balance: 400},
+			{Account: "WALLETS", Currency: "USD", Balance: 600},
+		},
+		Balanced: true,
+	}
+	mockUsecase.On("GetTrialBalance", mock.Anything).Return(mockResult, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/ledger/trial-balance", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"balanced":true`)
+	assert.Contains(t, w.Body.String(), `{"account":"CASH_IN","currency":"USD","balance":-1000}`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CreateHold_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	request := models.CreateHoldRequest{WalletID: walletID, Amount: 300, ExpiresIn: 3600}
+	mockResult := models.Hold{ID: "5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90", WalletID: walletID, Amount: 300, Status: models.HoldStatusActive}
+	mockUsecase.On("CreateHold", mock.Anything, request).Return(mockResult, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallets/"+walletID+"/holds", bytes.NewBufferString(`{"amount": 300, "expires_in": 3600}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"ACTIVE"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CaptureHold_WithoutBody(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	holdID := "5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90"
+	mockResult := models.CaptureHoldResponse{
+		Hold:        models.Hold{ID: holdID, Amount: 300, CapturedAmount: 300, Status: models.HoldStatusCaptured},
+		Transaction: models.Transaction{Operation: models.OperationCapture, Amount: 300, HoldID: &holdID},
+	}
+	mockUsecase.On("CaptureHold", mock.Anything, models.CaptureHoldRequest{HoldID: holdID}).Return(mockResult, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/holds/"+holdID+"/capture", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"CAPTURED"`)
+	assert.Contains(t, w.Body.String(), `"operation":"CAPTURE"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CaptureHold_FrozenWallet(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	holdID := "5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90"
+	mockUsecase.On("CaptureHold", mock.Anything, models.CaptureHoldRequest{HoldID: holdID}).
+		Return(models.CaptureHoldResponse{}, fmt.Errorf("pgRepo.CaptureHold: %w", models.ErrWalletFrozen))
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/holds/"+holdID+"/capture", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"wallet_frozen"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_VoidHold_NotActive(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	holdID := "5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90"
+	mockUsecase.On("VoidHold", mock.Anything, holdID).
+		Return(models.Hold{}, fmt.Errorf("pgRepo.VoidHold: %w", models.ErrHoldNotActive))
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/holds/"+holdID+"/void", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"hold_not_active"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_GetHold_NotFound(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	holdID := "5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90"
+	mockUsecase.On("GetHold", mock.Anything, holdID).
+		Return(models.Hold{}, fmt.Errorf("pgRepo.GetHold: %w", models.ErrHoldNotFound))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/holds/"+holdID, nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CreateQuote_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.CreateQuoteRequest{FromCurrency: "EUR", ToCurrency: "USD", Amount: 1000}
+	mockResult := models.Quote{
+		ID: "0f4e2a9c-7b3d-4e61-8c5a-1d9b6e3f2a70", FromCurrency: "EUR", ToCurrency: "USD",
+		Rate: "1.0850000000", Amount: 1000, ConvertedAmount: 1085,
+	}
+	mockUsecase.On("CreateQuote", mock.Anything, request).Return(mockResult, nil)
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/fx/quotes", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"rate":"1.0850000000"`)
+	assert.Contains(t, w.Body.String(), `"converted_amount":1085`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_Transfer_RateUnavailable(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.TransferRequest{
+		FromWalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		ToWalletID:   "c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34",
+		Amount:       1000,
+	}
+	mockUsecase.On("Transfer", mock.Anything, request).
+		Return(models.Transfer{}, fmt.Errorf("usecase.Transfer: %w", models.ErrRateUnavailable))
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"rate_unavailable"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_ReverseTransaction_Partial(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	transactionID := "3d6f1b2a-8c4e-4a7f-9b0d-5e2c7a1f6b38"
+	amount := int64(40)
+	mockResult := models.Reversal{
+		Original: models.Transaction{ID: transactionID, Operation: models.OperationWithdraw, Amount: 100, ReversedAmount: 40, Status: models.TransactionStatusPartiallyReversed},
+		Reversal: models.Transaction{Operation: models.OperationRefund, Amount: 40, OriginalTransactionID: &transactionID, Status: models.TransactionStatusCompleted},
+	}
+	mockUsecase.On("ReverseTransaction", mock.Anything, models.ReverseTransactionRequest{TransactionID: transactionID, Amount: &amount}).Return(mockResult, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/transactions/"+transactionID+"/reverse", bytes.NewBufferString(`{"amount": 40}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"PARTIALLY_REVERSED"`)
+	assert.Contains(t, w.Body.String(), `"original_transaction_id":"`+transactionID+`"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_ReverseTransaction_Exceeded(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	transactionID := "3d6f1b2a-8c4e-4a7f-9b0d-5e2c7a1f6b38"
+	mockUsecase.On("ReverseTransaction", mock.Anything, models.ReverseTransactionRequest{TransactionID: transactionID}).
+		Return(models.Reversal{}, fmt.Errorf("pgRepo.ReverseTransaction: %w", models.ErrReversalExceeded))
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/transactions/"+transactionID+"/reverse", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"reversal_exceeded"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_FreezeWallet_Success(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	request := models.WalletStatusRequest{WalletID: walletID, Reason: "chargeback fraud", BlockDeposits: true}
+	mockUsecase.On("FreezeWallet", mock.Anything, request).
+		Return(models.Wallet{ID: walletID, Status: models.WalletStatusFrozen, DepositsBlocked: true}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/wallets/"+walletID+"/freeze",
+		bytes.NewBufferString(`{"reason": "chargeback fraud", "block_deposits": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"FROZEN"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CloseWallet_NotEmpty(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	mockUsecase.On("CloseWallet", mock.Anything, models.WalletStatusRequest{WalletID: walletID, Reason: "customer request"}).
+		Return(models.Wallet{}, fmt.Errorf("pgRepo.ChangeWalletStatus: %w", models.ErrWalletNotEmpty))
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/wallets/"+walletID+"/close",
+		bytes.NewBufferString(`{"reason": "customer request"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"wallet_not_empty"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_WalletTransaction_FrozenWallet(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.WalletTransaction{WalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2", Operation: "WITHDRAW", Amount: 100}
+	mockUsecase.On("WalletTransaction", mock.Anything, request).
+		Return(models.Transaction{}, fmt.Errorf("usecase.WalletTransaction: %w", models.ErrWalletFrozen))
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"wallet_frozen"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_WalletTransaction_LimitExceeded(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.WalletTransaction{WalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2", Operation: "WITHDRAW", Amount: 300}
+	err := fmt.Errorf("usecase.WalletTransaction: %w", &models.LimitExceededError{Rule: models.RuleDailyWithdrawalCap, Limit: 1000})
+	mockUsecase.On("WalletTransaction", mock.Anything, request).Return(models.Transaction{}, err)
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{
+		"code": "limit_exceeded",
+		"error": "transaction limit exceeded",
+		"details": {"rule": "daily_withdrawal_cap", "limit": 1000}
+	}`, w.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_SetTierLimits(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	dailyCap := int64(50000)
+	request := models.SetLimitsRequest{Tier: "UNVERIFIED", Limits: models.Limits{DailyWithdrawalCap: &dailyCap}}
+	mockUsecase.On("SetTierLimits", mock.Anything, request).Return(request.Limits, nil)
+
+	req, _ := http.NewRequest(http.MethodPut, "/api/v1/admin/tiers/UNVERIFIED/limits", bytes.NewBufferString(`{"daily_withdrawal_cap": 50000}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"daily_withdrawal_cap":50000`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_WalletTransaction_CreditLimitExceeded(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.WalletTransaction{WalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2", Operation: "WITHDRAW", Amount: 2000}
+	err := fmt.Errorf("pgRepo.WalletTransactionWithdraw: %w", &models.CreditLimitExceededError{Available: 1500, CreditLimit: 5000})
+	mockUsecase.On("WalletTransaction", mock.Anything, request).Return(models.Transaction{}, err)
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{
+		"code": "credit_limit_exceeded",
+		"error": "credit line is exhausted",
+		"details": {"available_balance": 1500, "credit_limit": 5000}
+	}`, w.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_SetCreditLimit(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	creditLimit := int64(5000)
+	request := models.SetCreditLimitRequest{WalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2", CreditLimit: &creditLimit}
+	mockUsecase.On("SetCreditLimit", mock.Anything, request).
+		Return(models.Wallet{ID: request.WalletID, Balance: -1200, CreditLimit: creditLimit}, nil)
+
+	req, _ := http.NewRequest(http.MethodPut, "/api/v1/admin/wallets/7b7ad84a-cb3e-4734-8e80-98aef40122d2/credit-limit", bytes.NewBufferString(`{"credit_limit": 5000}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"credit_limit":5000`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_CreateWebhook(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.CreateWebhookRequest{URL: "https://partner.example.com/hooks", EventTypes: []string{models.WebhookEventDeposit}}
+	mockUsecase.On("CreateWebhook", mock.Anything, request).
+		Return(models.WebhookEndpoint{ID: "3d2c7a3e-5f0b-4c41-9d3e-0c6f4c1a9b10", URL: request.URL, EventTypes: request.EventTypes, Secret: "whsec_abc", Active: true}, nil)
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/webhooks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"whsec_abc"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_GetWebhookDeliveries_DeadLetter(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	mockUsecase.On("GetWebhookDeliveries", mock.Anything, models.GetWebhookDeliveriesRequest{Status: models.WebhookDeliveryDead}).
+		Return([]models.WebhookDelivery{{ID: "d1", Status: models.WebhookDeliveryDead, Attempts: 8, Payload: json.RawMessage(`{}`)}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/webhook-deliveries?status=DEAD", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"DEAD"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_ReplayWebhookDelivery_NotFound(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	mockUsecase.On("ReplayWebhookDelivery", mock.Anything, "d1").
+		Return(models.WebhookDelivery{}, fmt.Errorf("pgRepo.ReplayWebhookDelivery: %w", models.ErrDeliveryNotFound))
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/webhook-deliveries/d1/replay", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"webhook_delivery_not_found"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_Router_RequiresAPIKey(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server})
+
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "").
+		Return(models.APIKey{}, fmt.Errorf("usecase.AuthenticateAPIKey: %w", models.ErrUnauthorized))
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"unauthorized"`)
+	mockUsecase.AssertNotCalled(t, "WalletTransaction", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "RecordAPIKeyUsage", mock.Anything, mock.Anything)
+}
+
+func Test_Router_ScopeForbidden(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server})
+
+	reader := models.APIKey{ID: "k1", Scopes: []string{models.ScopeBalanceRead}}
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "wk_reader").Return(reader, nil)
+	mockUsecase.On("RecordAPIKeyUsage", mock.Anything, mock.MatchedBy(func(u models.APIKeyUsage) bool {
+		return u.KeyID == "k1" && u.Route == "/api/v1/wallet" && u.Status == http.StatusForbidden
+	})).Return(nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewBufferString(`{}`))
+	req.Header.Set("X-API-Key", "wk_reader")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"forbidden"`)
+	mockUsecase.AssertExpectations(t)
+	mockUsecase.AssertNotCalled(t, "WalletTransaction", mock.Anything, mock.Anything)
+}
+
+func Test_Router_ScopeAllowed(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server})
+
+	reader := models.APIKey{ID: "k1", Scopes: []string{models.ScopeBalanceRead}}
+	mockUsecase.On("AuthenticateAPIKey", mock.Anything, "wk_reader").Return(reader, nil)
+	mockUsecase.On("GetBalance", mock.Anything, "7b7ad84a-cb3e-4734-8e80-98aef40122d2").Return(models.GetBalanceResponse{Currency: "USD"}, nil)
+	mockUsecase.On("RecordAPIKeyUsage", mock.Anything, mock.MatchedBy(func(u models.APIKeyUsage) bool {
+		return u.Status == http.StatusOK && u.Method == http.MethodGet
+	})).Return(nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id=7b7ad84a-cb3e-4734-8e80-98aef40122d2", nil)
+	req.Header.Set("X-API-Key", "wk_reader")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_RotateAPIKey(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.RotateAPIKeyRequest{KeyID: "k1", GracePeriod: 3600}
+	mockUsecase.On("RotateAPIKey", mock.Anything, request).Return(models.APIKey{ID: "k2", Key: "wk_new", RotatedFrom: &request.KeyID}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/api-keys/k1/rotate", bytes.NewBufferString(`{"grace_period": 3600}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"wk_new"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+const (
+	testIssuer   = "https://id.example.com/"
+	testAudience = "payment-service"
+)
+
+// jwtRouter returns a router that accepts bearer tokens signed by the
+// returned key, published under key ID "k1" in a JWKS file.
+func jwtRouter(t *testing.T, mockUsecase *MockUsecase) (*gin.Engine, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "k1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.NewKeySet(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &transport.Server{Usecase: mockUsecase, Tokens: auth.NewVerifier(keys, testIssuer, testAudience)}
+	return transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server}), key
+}
+
+func signedToken(t *testing.T, key *rsa.PrivateKey, claims jwt.RegisteredClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func userClaims(subject string) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    testIssuer,
+		Audience:  jwt.ClaimStrings{testAudience},
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func Test_Router_BearerToken(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	router, key := jwtRouter(t, mockUsecase)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	mockUsecase.On("GetBalance", mock.MatchedBy(func(ctx context.Context) bool {
+		subject, ok := auth.SubjectFromContext(ctx)
+		return ok && subject == "user-1"
+	}), walletID).Return(models.GetBalanceResponse{Currency: "USD"}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id="+walletID, nil)
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, key, userClaims("user-1")))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUsecase.AssertExpectations(t)
+	mockUsecase.AssertNotCalled(t, "AuthenticateAPIKey", mock.Anything, mock.Anything)
+}
+
+func Test_Router_BearerTokenRejected(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	router, key := jwtRouter(t, mockUsecase)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	wrongAudience := userClaims("user-1")
+	wrongAudience.Audience = jwt.ClaimStrings{"another-service"}
+	wrongIssuer := userClaims("user-1")
+	wrongIssuer.Issuer = "https://evil.example.com/"
+	expired := userClaims("user-1")
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	noExpiry := userClaims("user-1")
+	noExpiry.ExpiresAt = nil
+
+	tokens := map[string]string{
+		"wrong audience": signedToken(t, key, wrongAudience),
+		"wrong issuer":   signedToken(t, key, wrongIssuer),
+		"expired":        signedToken(t, key, expired),
+		"no expiry":      signedToken(t, key, noExpiry),
+		"no subject":     signedToken(t, key, userClaims("")),
+		"wrong key":      signedToken(t, otherKey, userClaims("user-1")),
+		"malformed":      "not.a.token",
+	}
+	for name, token := range tokens {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id=7b7ad84a-cb3e-4734-8e80-98aef40122d2", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+	mockUsecase.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything)
+}
+
+func Test_Router_BearerTokenOnAdminRoute(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	router, key := jwtRouter(t, mockUsecase)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/admin/api-keys", nil)
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, key, userClaims("user-1")))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockUsecase.AssertNotCalled(t, "GetAPIKeys", mock.Anything)
+}
+
+func Test_Router_BearerTokenNotConfigured(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := transport.NewRouterWithGinEngine(gin.New(), transport.ApiHandleFunctions{Server: *server})
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/wallets?id=7b7ad84a-cb3e-4734-8e80-98aef40122d2", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockUsecase.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything)
+}
+
+func Test_AddWalletOwner(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.WalletOwnerRequest{WalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2", Subject: "auth0|user-1"}
+	mockUsecase.On("AddWalletOwner", mock.Anything, request).
+		Return(models.WalletOwner{WalletID: request.WalletID, Subject: request.Subject}, nil)
+
+	req, _ := http.NewRequest(http.MethodPut, "/api/v1/admin/wallets/7b7ad84a-cb3e-4734-8e80-98aef40122d2/owners/auth0%7Cuser-1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"subject":"auth0|user-1"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func Test_RemoveWalletOwner_NotFound(t *testing.T) {
+	mockUsecase := new(MockUsecase)
+	server := &transport.Server{Usecase: mockUsecase}
+	router := setupRouter(server)
+
+	request := models.WalletOwnerRequest{WalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2", Subject: "user-1"}
+	mockUsecase.On("RemoveWalletOwner", mock.Anything, request).
+		Return(fmt.Errorf("pgRepo.RemoveWalletOwner: %w", models.ErrOwnerNotFound))
+
+	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/admin/wallets/7b7ad84a-cb3e-4734-8e80-98aef40122d2/owners/user-1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"wallet_owner_not_found"`)
+	mockUsecase.AssertExpectations(t)
+}
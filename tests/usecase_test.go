@@ -1,43 +1,286 @@
 package tests
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/SerzhLimon/PaymentService/internal/auth"
+	"github.com/SerzhLimon/PaymentService/internal/fx"
 	"github.com/SerzhLimon/PaymentService/internal/models"
+	"github.com/SerzhLimon/PaymentService/internal/outbox"
 	"github.com/SerzhLimon/PaymentService/internal/usecase"
+	"github.com/SerzhLimon/PaymentService/internal/webhook"
 )
 
+var testRates, _ = fx.NewStaticProvider(map[string]string{
+	"EUR/USD": "1.0850",
+	"USD/JPY": "151.20",
+})
+
+var activeWallet = models.Wallet{
+	ID:       "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+	Currency: "USD",
+	Status:   models.WalletStatusActive,
+}
+
 type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) WalletTransactionDeposit(walletID uuid.UUID, amount int64) error {
-	args := m.Called(walletID, amount)
+func (m *MockRepository) WalletTransactionDeposit(ctx context.Context, walletID uuid.UUID, amount int64, idem models.Idempotency) (models.Transaction, error) {
+	args := m.Called(ctx, walletID, amount, idem)
+	return args.Get(0).(models.Transaction), args.Error(1)
+}
+
+func (m *MockRepository) WalletTransactionWithdraw(ctx context.Context, walletID uuid.UUID, amount int64, idem models.Idempotency) (models.Transaction, error) {
+	args := m.Called(ctx, walletID, amount, idem)
+	return args.Get(0).(models.Transaction), args.Error(1)
+}
+
+func (m *MockRepository) Transfer(ctx context.Context, from, to uuid.UUID, amount int64, conv *models.Conversion) (models.Transfer, error) {
+	args := m.Called(ctx, from, to, amount, conv)
+	return args.Get(0).(models.Transfer), args.Error(1)
+}
+
+func (m *MockRepository) GetBalance(ctx context.Context, walletID uuid.UUID) (models.GetBalanceResponse, error) {
+	args := m.Called(ctx, walletID)
+	return args.Get(0).(models.GetBalanceResponse), args.Error(1)
+}
+
+func (m *MockRepository) GetTransactions(ctx context.Context, filter models.TransactionFilter) ([]models.Transaction, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]models.Transaction), args.Error(1)
+}
+
+func (m *MockRepository) CreateWallet(ctx context.Context, wallet models.Wallet) (models.Wallet, error) {
+	args := m.Called(ctx, wallet)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockRepository) ReconcileWallet(ctx context.Context, walletID uuid.UUID) (models.Reconciliation, error) {
+	args := m.Called(ctx, walletID)
+	return args.Get(0).(models.Reconciliation), args.Error(1)
+}
+
+func (m *MockRepository) GetTrialBalance(ctx context.Context) (models.TrialBalance, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(models.TrialBalance), args.Error(1)
+}
+
+func (m *MockRepository) GetWallet(ctx context.Context, id uuid.UUID) (models.Wallet, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockRepository) CreateQuote(ctx context.Context, quote models.Quote) (models.Quote, error) {
+	args := m.Called(ctx, quote)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+func (m *MockRepository) GetQuote(ctx context.Context, id uuid.UUID) (models.Quote, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+func (m *MockRepository) ReverseTransaction(ctx context.Context, id uuid.UUID, amount int64) (models.Reversal, error) {
+	args := m.Called(ctx, id, amount)
+	return args.Get(0).(models.Reversal), args.Error(1)
+}
+
+func (m *MockRepository) ChangeWalletStatus(ctx context.Context, id uuid.UUID, status string, blockDeposits bool, reason string) (models.Wallet, error) {
+	args := m.Called(ctx, id, status, blockDeposits, reason)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockRepository) GetWalletStatusHistory(ctx context.Context, id uuid.UUID) ([]models.WalletStatusChange, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]models.WalletStatusChange), args.Error(1)
+}
+
+func (m *MockRepository) SetWalletTier(ctx context.Context, id uuid.UUID, tier string) (models.Wallet, error) {
+	args := m.Called(ctx, id, tier)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockRepository) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockRepository) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockRepository) WalletTransactionWithdraw(walletID uuid.UUID, amount int64) error {
-	args := m.Called(walletID, amount)
+func (m *MockRepository) RecordOutboxFailure(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	args := m.Called(ctx, id, lastError, nextAttemptAt, dead)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetBalance(walletID uuid.UUID) (models.GetBalanceResponse, error) {
-	args := m.Called(walletID)
-	return args.Get(0).(models.GetBalanceResponse), args.Error(1)
+func (m *MockRepository) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) (models.WebhookEndpoint, error) {
+	args := m.Called(ctx, endpoint)
+	return args.Get(0).(models.WebhookEndpoint), args.Error(1)
 }
 
-func (m *MockRepository) CreateWallet(walletID uuid.UUID) error {
-	return nil
+func (m *MockRepository) GetWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockRepository) GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (models.WebhookEndpoint, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockRepository) DisableWebhookEndpoint(ctx context.Context, id uuid.UUID) (models.WebhookEndpoint, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) RecordWebhookAttempt(ctx context.Context, attempt models.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, attempt, status, nextAttemptAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetWebhookDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockRepository) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockRepository) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockRepository) RotateAPIKey(ctx context.Context, id uuid.UUID, next models.APIKey, oldExpiresAt time.Time) (models.APIKey, error) {
+	args := m.Called(ctx, id, next, oldExpiresAt)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (models.APIKey, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.APIKey), args.Error(1)
+}
+
+func (m *MockRepository) RecordAPIKeyUsage(ctx context.Context, usage models.APIKeyUsage) error {
+	args := m.Called(ctx, usage)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetAPIKeyUsage(ctx context.Context, id uuid.UUID, limit int) ([]models.APIKeyUsage, error) {
+	args := m.Called(ctx, id, limit)
+	return args.Get(0).([]models.APIKeyUsage), args.Error(1)
+}
+
+func (m *MockRepository) SetCreditLimit(ctx context.Context, id uuid.UUID, creditLimit int64) (models.Wallet, error) {
+	args := m.Called(ctx, id, creditLimit)
+	return args.Get(0).(models.Wallet), args.Error(1)
+}
+
+func (m *MockRepository) GetOutboxLag(ctx context.Context) (models.OutboxLag, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(models.OutboxLag), args.Error(1)
+}
+
+func (m *MockRepository) IsWalletOwner(ctx context.Context, id uuid.UUID, subject string) (bool, error) {
+	args := m.Called(ctx, id, subject)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetWalletOwners(ctx context.Context, id uuid.UUID) ([]models.WalletOwner, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]models.WalletOwner), args.Error(1)
+}
+
+func (m *MockRepository) AddWalletOwner(ctx context.Context, id uuid.UUID, subject string) (models.WalletOwner, error) {
+	args := m.Called(ctx, id, subject)
+	return args.Get(0).(models.WalletOwner), args.Error(1)
+}
+
+func (m *MockRepository) RemoveWalletOwner(ctx context.Context, id uuid.UUID, subject string) error {
+	args := m.Called(ctx, id, subject)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetWalletLimits(ctx context.Context, id uuid.UUID, limits models.Limits) error {
+	args := m.Called(ctx, id, limits)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetTierLimits(ctx context.Context, tier string, limits models.Limits) error {
+	args := m.Called(ctx, tier, limits)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateHold(ctx context.Context, walletID uuid.UUID, amount int64, expiresAt time.Time) (models.Hold, error) {
+	args := m.Called(ctx, walletID, amount, expiresAt)
+	return args.Get(0).(models.Hold), args.Error(1)
+}
+
+func (m *MockRepository) GetHold(ctx context.Context, id uuid.UUID) (models.Hold, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Hold), args.Error(1)
+}
+
+func (m *MockRepository) CaptureHold(ctx context.Context, id uuid.UUID, amount int64) (models.CaptureHoldResponse, error) {
+	args := m.Called(ctx, id, amount)
+	return args.Get(0).(models.CaptureHoldResponse), args.Error(1)
+}
+
+func (m *MockRepository) VoidHold(ctx context.Context, id uuid.UUID) (models.Hold, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Hold), args.Error(1)
+}
+
+func (m *MockRepository) ExpireHolds(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func TestWalletTransaction_Success_Deposit(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	amount := int64(100)
 	data := models.WalletTransaction{
@@ -46,16 +289,17 @@ func TestWalletTransaction_Success_Deposit(t *testing.T) {
 		Amount:    amount,
 	}
 
-	mockRepo.On("WalletTransactionDeposit", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("WalletTransactionDeposit", mock.Anything, mock.Anything, amount, models.Idempotency{}).Return(models.Transaction{Amount: amount, Operation: data.Operation}, nil)
 
-	err := usecase.WalletTransaction(data)
+	res, err := usecase.WalletTransaction(context.Background(), data)
 	assert.NoError(t, err)
+	assert.Equal(t, amount, res.Amount)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestWalletTransaction_Success_Withdraw(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	amount := int64(50)
 	data := models.WalletTransaction{
@@ -64,16 +308,73 @@ func TestWalletTransaction_Success_Withdraw(t *testing.T) {
 		Amount:    amount,
 	}
 
-	mockRepo.On("WalletTransactionWithdraw", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("WalletTransactionWithdraw", mock.Anything, mock.Anything, amount, models.Idempotency{}).Return(models.Transaction{Amount: amount, Operation: data.Operation}, nil)
+
+	res, err := usecase.WalletTransaction(context.Background(), data)
+	assert.NoError(t, err)
+	assert.Equal(t, amount, res.Amount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWalletTransaction_Withdraw_InsufficientFunds(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	data := models.WalletTransaction{
+		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Operation: "WITHDRAW",
+		Amount:    1000,
+	}
+
+	mockRepo.On("WalletTransactionWithdraw", mock.Anything, mock.Anything, int64(1000), models.Idempotency{}).
+		Return(models.Transaction{}, &models.InsufficientFundsError{Available: 300})
+
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.ErrorIs(t, err, models.ErrInsufficientFunds)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWalletTransaction_IdempotencyKey(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	data := models.WalletTransaction{
+		WalletID:       "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Operation:      "DEPOSIT",
+		Amount:         100,
+		IdempotencyKey: "retry-1",
+	}
+
+	mockRepo.On("GetWallet", mock.Anything, mock.Anything).Return(activeWallet, nil)
+	var hashes []string
+	mockRepo.On("WalletTransactionDeposit", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(idem models.Idempotency) bool {
+		hashes = append(hashes, idem.RequestHash)
+		return idem.Key == "retry-1" && idem.RequestHash != ""
+	})).Return(models.Transaction{}, nil)
+
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.NoError(t, err)
+	_, err = usecase.WalletTransaction(context.Background(), data)
+	assert.NoError(t, err)
+
+	data.Amount = 200
+	_, err = usecase.WalletTransaction(context.Background(), data)
+	assert.NoError(t, err)
 
-	err := usecase.WalletTransaction(data)
+	data.Currency = "USD"
+	_, err = usecase.WalletTransaction(context.Background(), data)
 	assert.NoError(t, err)
+
+	assert.Len(t, hashes, 4)
+	assert.Equal(t, hashes[0], hashes[1])
+	assert.NotEqual(t, hashes[0], hashes[2])
+	assert.NotEqual(t, hashes[2], hashes[3])
 	mockRepo.AssertExpectations(t)
 }
 
 func TestWalletTransaction_InvalidUUID(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	data := models.WalletTransaction{
 		WalletID:  "invalid-uuid",
@@ -81,13 +382,13 @@ func TestWalletTransaction_InvalidUUID(t *testing.T) {
 		Amount:    100,
 	}
 
-	err := usecase.WalletTransaction(data)
-	assert.Error(t, err)
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
 }
 
 func TestWalletTransaction_InvalidAmount(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	data := models.WalletTransaction{
 		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
@@ -95,13 +396,13 @@ func TestWalletTransaction_InvalidAmount(t *testing.T) {
 		Amount:    -100,
 	}
 
-	err := usecase.WalletTransaction(data)
-	assert.Error(t, err)
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.ErrorIs(t, err, models.ErrInvalidAmount)
 }
 
 func TestWalletTransaction_UnknownOperation(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	data := models.WalletTransaction{
 		WalletID:  "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
@@ -109,42 +410,1146 @@ func TestWalletTransaction_UnknownOperation(t *testing.T) {
 		Amount:    100,
 	}
 
-	err := usecase.WalletTransaction(data)
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.ErrorIs(t, err, models.ErrUnknownOperation)
+}
+
+func TestTransfer_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	from := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	to := uuid.MustParse("c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34")
+	mockRepo.On("GetWallet", mock.Anything, from).Return(models.Wallet{ID: from.String(), Currency: "USD"}, nil)
+	mockRepo.On("GetWallet", mock.Anything, to).Return(models.Wallet{ID: to.String(), Currency: "USD"}, nil)
+	mockRepo.On("Transfer", mock.Anything, from, to, int64(250), (*models.Conversion)(nil)).Return(models.Transfer{Amount: 250}, nil)
+
+	res, err := usecase.Transfer(context.Background(), models.TransferRequest{
+		FromWalletID: from.String(),
+		ToWalletID:   to.String(),
+		Amount:       250,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(250), res.Amount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransfer_SameWallet(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	_, err := usecase.Transfer(context.Background(), models.TransferRequest{
+		FromWalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		ToWalletID:   "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		Amount:       250,
+	})
 	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestGetBalance_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
-	mockRepo.On("GetBalance", mock.Anything).Return(models.GetBalanceResponse{Amount: 100.0}, nil)
+	mockRepo.On("GetBalance", mock.Anything, mock.Anything).Return(models.GetBalanceResponse{Currency: "EUR", Ledger: 10050, Available: 6000}, nil)
 
-	result, err := usecase.GetBalance(walletID)
+	result, err := usecase.GetBalance(context.Background(), walletID)
 	assert.NoError(t, err)
-	assert.Equal(t, 100.0, result.Amount)
+	assert.Equal(t, int64(10050), result.Ledger)
+	assert.Equal(t, "100.50", result.LedgerFormatted)
+	assert.Equal(t, "60.00", result.AvailableFormatted)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestGetBalance_InvalidUUID(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	// Test invalid UUID for GetBalance
-	_, err := usecase.GetBalance("invalid-uuid")
-	assert.Error(t, err)
+	_, err := usecase.GetBalance(context.Background(), "invalid-uuid")
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
+}
+
+func TestGetBalance_WalletNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	mockRepo.On("GetBalance", mock.Anything, mock.Anything).Return(models.GetBalanceResponse{}, models.ErrWalletNotFound)
+
+	_, err := usecase.GetBalance(context.Background(), walletID)
+	assert.ErrorIs(t, err, models.ErrWalletNotFound)
+	mockRepo.AssertExpectations(t)
 }
 
 func TestGetBalance_Failure(t *testing.T) {
 	mockRepo := new(MockRepository)
-	usecase := usecase.NewUsecase(mockRepo)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
 
 	// Test failure while retrieving balance
 	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
-	mockRepo.On("GetBalance", mock.Anything).Return(models.GetBalanceResponse{}, errors.New("failed to get balance"))
+	mockRepo.On("GetBalance", mock.Anything, mock.Anything).Return(models.GetBalanceResponse{}, errors.New("failed to get balance"))
 
-	_, err := usecase.GetBalance(walletID)
+	_, err := usecase.GetBalance(context.Background(), walletID)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to get balance")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestGetTransactions_Pagination(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	page := []models.Transaction{
+		{ID: "0b8a3c55-4b53-4b0e-9a43-6f0f2f6a1d10", WalletID: walletID, CreatedAt: time.Date(2024, 11, 20, 12, 0, 2, 0, time.UTC)},
+		{ID: "1c9b4d66-5c64-4c1f-8b54-7a1a3a7b2e21", WalletID: walletID, CreatedAt: time.Date(2024, 11, 20, 12, 0, 1, 0, time.UTC)},
+		{ID: "2dac5e77-6d75-4d2a-9c65-8b2b4b8c3f32", WalletID: walletID, CreatedAt: time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)},
+	}
+	mockRepo.On("GetTransactions", mock.Anything, mock.MatchedBy(func(f models.TransactionFilter) bool {
+		return f.Limit == 3 && f.Descending && f.After == nil && f.Operation == "DEPOSIT"
+	})).Return(page, nil)
+
+	first, err := usecase.GetTransactions(context.Background(), models.GetTransactionsRequest{
+		WalletID:  walletID,
+		Operation: "DEPOSIT",
+		Limit:     2,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, first.Transactions, 2)
+	assert.NotEmpty(t, first.NextCursor)
+
+	mockRepo.On("GetTransactions", mock.Anything, mock.MatchedBy(func(f models.TransactionFilter) bool {
+		return f.After != nil && f.After.ID.String() == page[1].ID && f.After.CreatedAt.Equal(page[1].CreatedAt)
+	})).Return(page[2:], nil)
+
+	second, err := usecase.GetTransactions(context.Background(), models.GetTransactionsRequest{
+		WalletID:  walletID,
+		Operation: "DEPOSIT",
+		Limit:     2,
+		Cursor:    first.NextCursor,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, second.Transactions, 1)
+	assert.Empty(t, second.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTransactions_InvalidFilter(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	minAmount, maxAmount := int64(100), int64(10)
+	requests := []models.GetTransactionsRequest{
+		{WalletID: "invalid-uuid"},
+		{WalletID: walletID, Operation: "TRANSFER"},
+		{WalletID: walletID, MinAmount: &minAmount, MaxAmount: &maxAmount},
+		{WalletID: walletID, From: "yesterday"},
+		{WalletID: walletID, Sort: "random"},
+		{WalletID: walletID, Cursor: "not-a-cursor"},
+		{WalletID: walletID, Limit: 1000},
+	}
+
+	for _, req := range requests {
+		_, err := usecase.GetTransactions(context.Background(), req)
+		assert.Error(t, err, "%+v", req)
+	}
+	mockRepo.AssertNotCalled(t, "GetTransactions", mock.Anything, mock.Anything)
+}
+
+func TestCreateWallet_GeneratesID(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	mockRepo.On("CreateWallet", mock.Anything, mock.MatchedBy(func(w models.Wallet) bool {
+		_, err := uuid.Parse(w.ID)
+		return err == nil && w.Currency == "USD" && string(w.Metadata) == "{}" && w.OwnerRef == nil
+	})).Return(models.Wallet{}, nil)
+
+	_, err := usecase.CreateWallet(context.Background(), models.CreateWalletRequest{})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateWallet_ClientSuppliedID(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	req := models.CreateWalletRequest{
+		ID:       "7b7ad84a-cb3e-4734-8e80-98aef40122d2",
+		OwnerRef: "customer-42",
+		Currency: "EUR",
+		Metadata: json.RawMessage(`{"tier":"gold"}`),
+	}
+	mockRepo.On("CreateWallet", mock.Anything, mock.MatchedBy(func(w models.Wallet) bool {
+		return w.ID == req.ID && w.Currency == "EUR" && *w.OwnerRef == "customer-42"
+	})).Return(models.Wallet{ID: req.ID}, nil)
+
+	res, err := usecase.CreateWallet(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, req.ID, res.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateWallet_InvalidRequest(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	requests := []models.CreateWalletRequest{
+		{ID: "invalid-uuid"},
+		{Currency: "euro"},
+		{Metadata: json.RawMessage(`[1, 2, 3]`)},
+	}
+
+	for _, req := range requests {
+		_, err := usecase.CreateWallet(context.Background(), req)
+		assert.Error(t, err, "%+v", req)
+	}
+	mockRepo.AssertNotCalled(t, "CreateWallet", mock.Anything, mock.Anything)
+}
+
+func TestReconcileWallet_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	mockResult := models.Reconciliation{WalletID: walletID.String(), Balance: 500, LedgerBalance: 450, Difference: 50}
+	mockRepo.On("ReconcileWallet", mock.Anything, walletID).Return(mockResult, nil)
+
+	res, err := usecase.ReconcileWallet(context.Background(), walletID.String())
+	assert.NoError(t, err)
+	assert.False(t, res.Balanced)
+	assert.Equal(t, int64(50), res.Difference)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReconcileWallet_InvalidUUID(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	_, err := usecase.ReconcileWallet(context.Background(), "invalid-uuid")
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	mockRepo.AssertNotCalled(t, "ReconcileWallet", mock.Anything, mock.Anything)
+}
+
+func TestCreateHold_DefaultExpiry(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	inADay := mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Until(expiresAt) > 23*time.Hour && time.Until(expiresAt) <= 24*time.Hour
+	})
+	mockRepo.On("CreateHold", mock.Anything, walletID, int64(300), inADay).
+		Return(models.Hold{WalletID: walletID.String(), Amount: 300, Status: models.HoldStatusActive}, nil)
+
+	res, err := usecase.CreateHold(context.Background(), models.CreateHoldRequest{WalletID: walletID.String(), Amount: 300})
+	assert.NoError(t, err)
+	assert.Equal(t, models.HoldStatusActive, res.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateHold_InvalidRequest(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := "7b7ad84a-cb3e-4734-8e80-98aef40122d2"
+	requests := []models.CreateHoldRequest{
+		{WalletID: "invalid-uuid", Amount: 100},
+		{WalletID: walletID, Amount: 0},
+		{WalletID: walletID, Amount: 100, ExpiresIn: -1},
+		{WalletID: walletID, Amount: 100, ExpiresIn: 31 * 24 * 3600},
+	}
+
+	for _, req := range requests {
+		_, err := usecase.CreateHold(context.Background(), req)
+		assert.Error(t, err, "%+v", req)
+	}
+	mockRepo.AssertNotCalled(t, "CreateHold", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCaptureHold_FullAmountByDefault(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	holdID := uuid.MustParse("5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90")
+	mockRepo.On("GetHold", mock.Anything, holdID).Return(models.Hold{ID: holdID.String(), WalletID: activeWallet.ID, Amount: 300}, nil)
+	mockRepo.On("CaptureHold", mock.Anything, holdID, int64(300)).
+		Return(models.CaptureHoldResponse{Hold: models.Hold{CapturedAmount: 300, Status: models.HoldStatusCaptured}}, nil)
+
+	res, err := usecase.CaptureHold(context.Background(), models.CaptureHoldRequest{HoldID: holdID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), res.Hold.CapturedAmount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCaptureHold_PartialAmount(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	holdID := uuid.MustParse("5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90")
+	amount := int64(120)
+	mockRepo.On("GetHold", mock.Anything, holdID).Return(models.Hold{ID: holdID.String(), WalletID: activeWallet.ID, Amount: 300}, nil)
+	mockRepo.On("CaptureHold", mock.Anything, holdID, amount).
+		Return(models.CaptureHoldResponse{Hold: models.Hold{CapturedAmount: amount, Status: models.HoldStatusCaptured}}, nil)
+
+	res, err := usecase.CaptureHold(context.Background(), models.CaptureHoldRequest{HoldID: holdID.String(), Amount: &amount})
+	assert.NoError(t, err)
+	assert.Equal(t, amount, res.Hold.CapturedAmount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateHold_FrozenWallet(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := uuid.MustParse(activeWallet.ID)
+	mockRepo.On("CreateHold", mock.Anything, walletID, int64(300), mock.Anything).
+		Return(models.Hold{}, fmt.Errorf("pgRepo.CreateHold: %w", models.ErrWalletFrozen))
+
+	_, err := usecase.CreateHold(context.Background(), models.CreateHoldRequest{WalletID: activeWallet.ID, Amount: 300})
+	assert.ErrorIs(t, err, models.ErrWalletFrozen)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCaptureHold_FrozenWallet(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	holdID := uuid.MustParse("5a3c7a8e-6f1d-4f5e-9c1b-2d8e4a6b7c90")
+	mockRepo.On("GetHold", mock.Anything, holdID).Return(models.Hold{ID: holdID.String(), WalletID: activeWallet.ID, Amount: 300}, nil)
+	mockRepo.On("CaptureHold", mock.Anything, holdID, int64(300)).
+		Return(models.CaptureHoldResponse{}, fmt.Errorf("pgRepo.CaptureHold: %w", models.ErrWalletFrozen))
+
+	_, err := usecase.CaptureHold(context.Background(), models.CaptureHoldRequest{HoldID: holdID.String()})
+	assert.ErrorIs(t, err, models.ErrWalletFrozen)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWalletTransaction_CurrencyMatches(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	data := models.WalletTransaction{WalletID: walletID.String(), Operation: "DEPOSIT", Amount: 500, Currency: "EUR"}
+	mockRepo.On("GetWallet", mock.Anything, walletID).Return(models.Wallet{ID: walletID.String(), Currency: "EUR"}, nil)
+	mockRepo.On("WalletTransactionDeposit", mock.Anything, walletID, int64(500), models.Idempotency{}).
+		Return(models.Transaction{Amount: 500, Operation: data.Operation}, nil)
+
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWalletTransaction_CurrencyMismatch(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	walletID := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	data := models.WalletTransaction{WalletID: walletID.String(), Operation: "DEPOSIT", Amount: 500, Currency: "USD"}
+	mockRepo.On("GetWallet", mock.Anything, walletID).Return(models.Wallet{ID: walletID.String(), Currency: "EUR"}, nil)
+
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.ErrorIs(t, err, models.ErrCurrencyMismatch)
+	mockRepo.AssertNotCalled(t, "WalletTransactionDeposit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWalletTransaction_UnsupportedCurrency(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	data := models.WalletTransaction{WalletID: "7b7ad84a-cb3e-4734-8e80-98aef40122d2", Operation: "DEPOSIT", Amount: 500, Currency: "XXX"}
+
+	_, err := usecase.WalletTransaction(context.Background(), data)
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	mockRepo.AssertNotCalled(t, "GetWallet", mock.Anything, mock.Anything)
+}
+
+func TestFormatAmount(t *testing.T) {
+	cases := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{12345, "USD", "123.45"},
+		{5, "EUR", "0.05"},
+		{-250, "EUR", "-2.50"},
+		{1500, "JPY", "1500"},
+		{1, "KWD", "0.001"},
+		{-9223372036854775808, "USD", "-92233720368547758.08"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, models.FormatAmount(c.amount, c.currency), "%d %s", c.amount, c.currency)
+	}
+}
+
+func TestTransfer_CrossCurrency_LiveRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	from := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	to := uuid.MustParse("c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34")
+	mockRepo.On("GetWallet", mock.Anything, from).Return(models.Wallet{ID: from.String(), Currency: "EUR"}, nil)
+	mockRepo.On("GetWallet", mock.Anything, to).Return(models.Wallet{ID: to.String(), Currency: "USD"}, nil)
+	conv := &models.Conversion{FromCurrency: "EUR", ToCurrency: "USD", Rate: "1.0850000000", Amount: 1085}
+	mockRepo.On("Transfer", mock.Anything, from, to, int64(1000), conv).Return(models.Transfer{Amount: 1000, ToAmount: 1085}, nil)
+
+	res, err := usecase.Transfer(context.Background(), models.TransferRequest{
+		FromWalletID: from.String(),
+		ToWalletID:   to.String(),
+		Amount:       1000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1085), res.ToAmount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransfer_CrossCurrency_Quote(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	from := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	to := uuid.MustParse("c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34")
+	quoteID := uuid.MustParse("0f4e2a9c-7b3d-4e61-8c5a-1d9b6e3f2a70")
+	mockRepo.On("GetWallet", mock.Anything, from).Return(models.Wallet{ID: from.String(), Currency: "USD"}, nil)
+	mockRepo.On("GetWallet", mock.Anything, to).Return(models.Wallet{ID: to.String(), Currency: "EUR"}, nil)
+	mockRepo.On("GetQuote", mock.Anything, quoteID).Return(models.Quote{
+		ID: quoteID.String(), FromCurrency: "USD", ToCurrency: "EUR", Rate: "0.9200000000",
+		Amount: 1000, ConvertedAmount: 920, ExpiresAt: time.Now().Add(time.Minute),
+	}, nil)
+	conv := &models.Conversion{
+		FromCurrency: "USD", ToCurrency: "EUR", Rate: "0.9200000000", Amount: 920,
+		QuoteID: uuid.NullUUID{UUID: quoteID, Valid: true},
+	}
+	mockRepo.On("Transfer", mock.Anything, from, to, int64(1000), conv).Return(models.Transfer{Amount: 1000, ToAmount: 920}, nil)
+
+	_, err := usecase.Transfer(context.Background(), models.TransferRequest{
+		FromWalletID: from.String(),
+		ToWalletID:   to.String(),
+		Amount:       1000,
+		QuoteID:      quoteID.String(),
+	})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransfer_CrossCurrency_ExpiredQuote(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	from := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	to := uuid.MustParse("c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34")
+	quoteID := uuid.MustParse("0f4e2a9c-7b3d-4e61-8c5a-1d9b6e3f2a70")
+	mockRepo.On("GetWallet", mock.Anything, from).Return(models.Wallet{ID: from.String(), Currency: "USD"}, nil)
+	mockRepo.On("GetWallet", mock.Anything, to).Return(models.Wallet{ID: to.String(), Currency: "EUR"}, nil)
+	mockRepo.On("GetQuote", mock.Anything, quoteID).Return(models.Quote{
+		ID: quoteID.String(), FromCurrency: "USD", ToCurrency: "EUR", Rate: "0.9200000000",
+		Amount: 1000, ConvertedAmount: 920, ExpiresAt: time.Now().Add(-time.Second),
+	}, nil)
+
+	_, err := usecase.Transfer(context.Background(), models.TransferRequest{
+		FromWalletID: from.String(),
+		ToWalletID:   to.String(),
+		Amount:       1000,
+		QuoteID:      quoteID.String(),
+	})
+	assert.ErrorIs(t, err, models.ErrQuoteExpired)
+	mockRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateQuote_InverseRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, 30*time.Second)
+
+	inTTL := mock.MatchedBy(func(q models.Quote) bool {
+		return q.Rate == "0.0066137566" && q.ConvertedAmount == 661 && time.Until(q.ExpiresAt) <= 30*time.Second
+	})
+	mockRepo.On("CreateQuote", mock.Anything, inTTL).Return(models.Quote{ConvertedAmount: 661}, nil)
+
+	res, err := usecase.CreateQuote(context.Background(), models.CreateQuoteRequest{FromCurrency: "JPY", ToCurrency: "USD", Amount: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(661), res.ConvertedAmount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateQuote_RateUnavailable(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	_, err := usecase.CreateQuote(context.Background(), models.CreateQuoteRequest{FromCurrency: "GBP", ToCurrency: "CHF", Amount: 1000})
+	assert.ErrorIs(t, err, models.ErrRateUnavailable)
+	mockRepo.AssertNotCalled(t, "CreateQuote", mock.Anything, mock.Anything)
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		amount   int64
+		from, to string
+		rate     string
+		want     int64
+	}{
+		{1000, "EUR", "USD", "1.0850", 1085},
+		{1, "EUR", "USD", "1.0850", 1},
+		{1000, "USD", "JPY", "151.20", 1512},
+		{100, "JPY", "USD", "0.0066137566", 66},
+		{1, "USD", "KWD", "0.3075", 3},
+	}
+
+	for _, c := range cases {
+		rate, err := fx.ParseRate(c.rate)
+		assert.NoError(t, err)
+		got, err := fx.Convert(c.amount, c.from, c.to, rate)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, got, "%d %s -> %s @ %s", c.amount, c.from, c.to, c.rate)
+	}
+}
+
+func TestReverseTransaction_Full(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.MustParse("3d6f1b2a-8c4e-4a7f-9b0d-5e2c7a1f6b38")
+	mockRepo.On("ReverseTransaction", mock.Anything, id, int64(0)).Return(models.Reversal{
+		Original: models.Transaction{Status: models.TransactionStatusReversed},
+	}, nil)
+
+	res, err := usecase.ReverseTransaction(context.Background(), models.ReverseTransactionRequest{TransactionID: id.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, models.TransactionStatusReversed, res.Original.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReverseTransaction_InvalidAmount(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	amount := int64(-5)
+	_, err := usecase.ReverseTransaction(context.Background(), models.ReverseTransactionRequest{
+		TransactionID: "3d6f1b2a-8c4e-4a7f-9b0d-5e2c7a1f6b38",
+		Amount:        &amount,
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidAmount)
+	mockRepo.AssertNotCalled(t, "ReverseTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWalletTransaction_FrozenWallet(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	frozen := activeWallet
+	frozen.Status = models.WalletStatusFrozen
+	mockRepo.On("WalletTransactionWithdraw", mock.Anything, mock.Anything, int64(100), models.Idempotency{}).
+		Return(models.Transaction{}, fmt.Errorf("pgRepo.WalletTransactionWithdraw: %w", models.ErrWalletFrozen))
+
+	_, err := usecase.WalletTransaction(context.Background(), models.WalletTransaction{WalletID: frozen.ID, Operation: "WITHDRAW", Amount: 100})
+	assert.ErrorIs(t, err, models.ErrWalletFrozen)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWalletTransaction_FrozenWallet_ReplaysStoredResult(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	frozen := activeWallet
+	frozen.Status = models.WalletStatusFrozen
+	frozen.DepositsBlocked = true
+	mockRepo.On("WalletTransactionDeposit", mock.Anything, mock.Anything, int64(100), mock.Anything).
+		Return(models.Transaction{Amount: 100, Replayed: true}, nil)
+
+	res, err := usecase.WalletTransaction(context.Background(), models.WalletTransaction{
+		WalletID:       frozen.ID,
+		Operation:      "DEPOSIT",
+		Amount:         100,
+		IdempotencyKey: "retry-1",
+	})
+	assert.NoError(t, err)
+	assert.True(t, res.Replayed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransfer_ClosedDestination(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	from := uuid.MustParse("7b7ad84a-cb3e-4734-8e80-98aef40122d2")
+	to := uuid.MustParse("c3f1a7d2-91b4-4f5e-8a6d-2e7b9c0d1f34")
+	mockRepo.On("GetWallet", mock.Anything, from).Return(activeWallet, nil)
+	mockRepo.On("GetWallet", mock.Anything, to).Return(models.Wallet{ID: to.String(), Currency: "USD", Status: models.WalletStatusClosed}, nil)
+	mockRepo.On("Transfer", mock.Anything, from, to, int64(100), (*models.Conversion)(nil)).
+		Return(models.Transfer{}, fmt.Errorf("pgRepo.Transfer: %w", models.ErrWalletClosed))
+
+	_, err := usecase.Transfer(context.Background(), models.TransferRequest{FromWalletID: from.String(), ToWalletID: to.String(), Amount: 100})
+	assert.ErrorIs(t, err, models.ErrWalletClosed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFreezeWallet_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.MustParse(activeWallet.ID)
+	mockRepo.On("ChangeWalletStatus", mock.Anything, id, models.WalletStatusFrozen, true, "suspected account takeover").
+		Return(models.Wallet{ID: id.String(), Status: models.WalletStatusFrozen, DepositsBlocked: true}, nil)
+
+	res, err := usecase.FreezeWallet(context.Background(), models.WalletStatusRequest{
+		WalletID:      id.String(),
+		Reason:        "suspected account takeover",
+		BlockDeposits: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.WalletStatusFrozen, res.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCloseWallet_RequiresReason(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	_, err := usecase.CloseWallet(context.Background(), models.WalletStatusRequest{WalletID: activeWallet.ID})
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	mockRepo.AssertNotCalled(t, "ChangeWalletStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func limitedWallet(limits models.Limits) models.Wallet {
+	wallet := activeWallet
+	wallet.Limits = limits
+	return wallet
+}
+
+func TestLimits_MaxSingleAmount(t *testing.T) {
+	limits := models.Limits{MaxSingleAmount: int64Ptr(500)}
+
+	assert.False(t, limits.NeedsUsage(true))
+	assert.NoError(t, limits.Check(models.LimitUsage{}, 500, false))
+
+	var limitErr *models.LimitExceededError
+	err := limits.Check(models.LimitUsage{}, 501, false)
+	assert.ErrorIs(t, err, models.ErrLimitExceeded)
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, models.RuleMaxSingleAmount, limitErr.Rule)
+}
+
+func TestLimits_DailyWithdrawalCap(t *testing.T) {
+	limits := models.Limits{DailyWithdrawalCap: int64Ptr(1000)}
+	assert.True(t, limits.NeedsUsage(true))
+
+	var limitErr *models.LimitExceededError
+	err := limits.Check(models.LimitUsage{Available: 5000, WithdrawnToday: 800}, 300, true)
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, models.RuleDailyWithdrawalCap, limitErr.Rule)
+	assert.Equal(t, int64(1000), limitErr.Limit)
+
+	assert.NoError(t, limits.Check(models.LimitUsage{Available: 5000, WithdrawnToday: 700}, 300, true))
+}
+
+func TestLimits_DepositIgnoresWithdrawalCaps(t *testing.T) {
+	limits := models.Limits{DailyWithdrawalCap: int64Ptr(0), MinBalance: int64Ptr(100)}
+
+	assert.False(t, limits.NeedsUsage(false))
+	assert.NoError(t, limits.Check(models.LimitUsage{}, 300, false))
+}
+
+func TestLimits_MaxOperations(t *testing.T) {
+	limits := models.Limits{MaxOperations: int64Ptr(3), OperationsWindowSeconds: int64Ptr(60)}
+	assert.True(t, limits.NeedsUsage(false))
+
+	now := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+	windows := limits.Windows(now)
+	assert.Equal(t, now.Add(-time.Minute), windows.Operations)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), windows.Day)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), windows.Month)
+
+	var limitErr *models.LimitExceededError
+	err := limits.Check(models.LimitUsage{Operations: 3}, 10, false)
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, models.RuleMaxOperations, limitErr.Rule)
+}
+
+func TestLimits_MinBalance(t *testing.T) {
+	limits := models.Limits{MinBalance: int64Ptr(1000)}
+
+	var limitErr *models.LimitExceededError
+	err := limits.Check(models.LimitUsage{Available: 1500}, 600, true)
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, models.RuleMinBalance, limitErr.Rule)
+
+	assert.NoError(t, limits.Check(models.LimitUsage{Available: 1500}, 500, true))
+}
+
+func TestTransfer_LimitExceeded(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	from := uuid.MustParse(activeWallet.ID)
+	to := uuid.New()
+	mockRepo.On("GetWallet", mock.Anything, from).
+		Return(limitedWallet(models.Limits{MinBalance: int64Ptr(1000)}), nil)
+	mockRepo.On("GetWallet", mock.Anything, to).
+		Return(models.Wallet{ID: to.String(), Currency: "USD", Status: models.WalletStatusActive}, nil)
+	mockRepo.On("Transfer", mock.Anything, from, to, int64(600), (*models.Conversion)(nil)).
+		Return(models.Transfer{}, fmt.Errorf("pgRepo.Transfer: %w", &models.LimitExceededError{Rule: models.RuleMinBalance, Limit: 1000}))
+
+	_, err := usecase.Transfer(context.Background(), models.TransferRequest{FromWalletID: from.String(), ToWalletID: to.String(), Amount: 600})
+	var limitErr *models.LimitExceededError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, models.RuleMinBalance, limitErr.Rule)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetWalletLimits_RequiresWindowWithMaxOperations(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	_, err := usecase.SetWalletLimits(context.Background(), models.SetLimitsRequest{
+		WalletID: activeWallet.ID,
+		Limits:   models.Limits{MaxOperations: int64Ptr(10)},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	mockRepo.AssertNotCalled(t, "SetWalletLimits", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetWalletLimits_ReturnsEffectiveLimits(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.MustParse(activeWallet.ID)
+	limits := models.Limits{MaxSingleAmount: int64Ptr(10000)}
+	effective := models.Limits{MaxSingleAmount: int64Ptr(10000), DailyWithdrawalCap: int64Ptr(50000)}
+	mockRepo.On("SetWalletLimits", mock.Anything, id, limits).Return(nil)
+	mockRepo.On("GetWallet", mock.Anything, id).Return(limitedWallet(effective), nil)
+
+	res, err := usecase.SetWalletLimits(context.Background(), models.SetLimitsRequest{WalletID: id.String(), Limits: limits})
+	assert.NoError(t, err)
+	assert.Equal(t, effective, res)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetWalletTier_InvalidTier(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	_, err := usecase.SetWalletTier(context.Background(), models.SetTierRequest{WalletID: activeWallet.ID, Tier: "gold tier"})
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	mockRepo.AssertNotCalled(t, "SetWalletTier", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetCreditLimit_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.MustParse(activeWallet.ID)
+	mockRepo.On("SetCreditLimit", mock.Anything, id, int64(50000)).
+		Return(models.Wallet{ID: id.String(), CreditLimit: 50000}, nil)
+
+	res, err := usecase.SetCreditLimit(context.Background(), models.SetCreditLimitRequest{WalletID: id.String(), CreditLimit: int64Ptr(50000)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50000), res.CreditLimit)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetCreditLimit_Invalid(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	for _, creditLimit := range []*int64{nil, int64Ptr(-1)} {
+		_, err := usecase.SetCreditLimit(context.Background(), models.SetCreditLimitRequest{WalletID: activeWallet.ID, CreditLimit: creditLimit})
+		assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	}
+	mockRepo.AssertNotCalled(t, "SetCreditLimit", mock.Anything, mock.Anything, mock.Anything)
+}
+
+type recordingPublisher struct {
+	events []models.OutboxEvent
+	fail   map[int64]bool
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event models.OutboxEvent) error {
+	p.events = append(p.events, event)
+	if p.fail[event.ID] {
+		return errors.New("consumer unavailable")
+	}
+	return nil
+}
+
+var outboxRetry = webhook.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+
+func TestRelayOutbox_PublishesThroughPublisher(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	event := models.OutboxEvent{ID: 7, Type: models.EventBalanceChanged, AggregateID: activeWallet.ID, Payload: json.RawMessage(`{}`)}
+	mockRepo.On("ClaimOutboxEvents", mock.Anything, 50, mock.Anything).Return([]models.OutboxEvent{event}, nil)
+	mockRepo.On("MarkOutboxEventPublished", mock.Anything, int64(7)).Return(nil)
+
+	publisher := &recordingPublisher{}
+	published, err := usecase.RelayOutbox(context.Background(), publisher, outboxRetry, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []models.OutboxEvent{event}, publisher.events)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRelayOutbox_FailedEventDoesNotStopBatch(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	events := []models.OutboxEvent{
+		{ID: 1, Payload: json.RawMessage(`{}`)},
+		{ID: 2, Payload: json.RawMessage(`{}`), Attempts: 2},
+		{ID: 3, Payload: json.RawMessage(`{}`)},
+	}
+	mockRepo.On("ClaimOutboxEvents", mock.Anything, 10, mock.Anything).Return(events, nil)
+	inAMinute := mock.MatchedBy(func(next time.Time) bool {
+		return time.Until(next) > 0 && time.Until(next) <= time.Minute
+	})
+	mockRepo.On("RecordOutboxFailure", mock.Anything, int64(1), "consumer unavailable", inAMinute, false).Return(nil)
+	mockRepo.On("RecordOutboxFailure", mock.Anything, int64(2), "consumer unavailable", mock.Anything, true).Return(nil)
+	mockRepo.On("MarkOutboxEventPublished", mock.Anything, int64(3)).Return(nil)
+
+	publisher := &recordingPublisher{fail: map[int64]bool{1: true, 2: true}}
+	published, err := usecase.RelayOutbox(context.Background(), publisher, outboxRetry, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Len(t, publisher.events, 3)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	var received models.OutboxEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "7", r.Header.Get("X-Event-ID"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	publisher, err := outbox.NewPublisher(outbox.PublisherHTTP, server.URL, time.Second)
+	assert.NoError(t, err)
+
+	event := models.OutboxEvent{ID: 7, Type: models.EventBalanceChanged, AggregateID: activeWallet.ID, Payload: json.RawMessage(`{"amount":100}`)}
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+	assert.Equal(t, event.Type, received.Type)
+	assert.JSONEq(t, `{"amount":100}`, string(received.Payload))
+}
+
+func TestHTTPPublisher_FailedDelivery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	publisher := outbox.NewHTTPPublisher(server.URL, time.Second)
+	err := publisher.Publish(context.Background(), models.OutboxEvent{ID: 1, Payload: json.RawMessage(`{}`)})
+	assert.Error(t, err)
+}
+
+func TestNewPublisher_Unknown(t *testing.T) {
+	_, err := outbox.NewPublisher("carrier-pigeon", "", time.Second)
+	assert.Error(t, err)
+}
+
+func TestWebhookSign(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+
+	signature := webhook.Sign("whsec_test", time.Unix(1700000000, 0), body)
+	assert.Equal(t, "t=1700000000,v1="+hex.EncodeToString(mac.Sum(nil)), signature)
+}
+
+func TestWebhookClient_SendsSignedEvent(t *testing.T) {
+	var event models.WebhookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature := r.Header.Get(webhook.SignatureHeader)
+		ts, _ := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+		assert.Equal(t, webhook.Sign("whsec_test", time.Unix(ts, 0), body), signature)
+		assert.Equal(t, models.WebhookEventDeposit, r.Header.Get(webhook.EventHeader))
+		assert.NoError(t, json.Unmarshal(body, &event))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	attempt := webhook.NewClient(time.Second).Send(context.Background(), models.WebhookDelivery{
+		ID: "d1", EventID: 42, EventType: models.WebhookEventDeposit, Payload: json.RawMessage(`{"amount":100}`),
+		URL: server.URL, Secret: "whsec_test",
+	})
+	assert.True(t, attempt.Succeeded)
+	assert.Equal(t, http.StatusNoContent, *attempt.StatusCode)
+	assert.Equal(t, "evt_42", event.ID)
+	assert.JSONEq(t, `{"amount":100}`, string(event.Data))
+}
+
+func TestWebhookClient_FailedAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	attempt := webhook.NewClient(time.Second).Send(context.Background(), models.WebhookDelivery{ID: "d1", URL: server.URL, Payload: json.RawMessage(`{}`)})
+	assert.False(t, attempt.Succeeded)
+	assert.Equal(t, http.StatusInternalServerError, *attempt.StatusCode)
+	assert.NotNil(t, attempt.Error)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := webhook.RetryPolicy{MaxAttempts: 8, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+	assert.Equal(t, 10*time.Second, policy.Backoff(1))
+	assert.Equal(t, 20*time.Second, policy.Backoff(2))
+	assert.Equal(t, 40*time.Second, policy.Backoff(3))
+	assert.Equal(t, time.Minute, policy.Backoff(4))
+	assert.Equal(t, time.Minute, policy.Backoff(30))
+}
+
+type stubSender struct {
+	succeed bool
+}
+
+func (s stubSender) Send(_ context.Context, d models.WebhookDelivery) models.WebhookAttempt {
+	return models.WebhookAttempt{DeliveryID: d.ID, Succeeded: s.succeed, AttemptedAt: time.Now()}
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	policy := webhook.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+
+	cases := []struct {
+		name     string
+		succeed  bool
+		attempts int
+		status   string
+	}{
+		{"delivered", true, 0, models.WebhookDeliveryDelivered},
+		{"retried", false, 1, models.WebhookDeliveryPending},
+		{"dead-lettered", false, 2, models.WebhookDeliveryDead},
+	}
+	for _, tc := range cases {
+		mockRepo := new(MockRepository)
+		usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+		delivery := models.WebhookDelivery{ID: "d1", Attempts: tc.attempts}
+		mockRepo.On("ClaimWebhookDeliveries", mock.Anything, 10, mock.Anything).Return([]models.WebhookDelivery{delivery}, nil)
+		mockRepo.On("RecordWebhookAttempt", mock.Anything, mock.Anything, tc.status, mock.MatchedBy(func(next time.Time) bool {
+			if tc.status != models.WebhookDeliveryPending {
+				return true
+			}
+			return time.Until(next) > time.Minute && time.Until(next) <= 2*time.Minute
+		})).Return(nil)
+
+		delivered, err := usecase.DeliverWebhooks(context.Background(), stubSender{succeed: tc.succeed}, policy, 10)
+		assert.NoError(t, err, tc.name)
+		if tc.succeed {
+			assert.Equal(t, 1, delivered, tc.name)
+		}
+		mockRepo.AssertExpectations(t)
+	}
+}
+
+func TestCreateWebhook_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	invalid := []models.CreateWebhookRequest{
+		{URL: "ftp://example.com", EventTypes: []string{models.WebhookEventDeposit}},
+		{URL: "https://example.com/hooks"},
+		{URL: "https://example.com/hooks", EventTypes: []string{"wallet.unknown"}},
+		{URL: "https://example.com/hooks", WalletID: "not-a-uuid", EventTypes: []string{models.WebhookEventDeposit}},
+	}
+	for _, request := range invalid {
+		_, err := usecase.CreateWebhook(context.Background(), request)
+		assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	}
+	mockRepo.AssertNotCalled(t, "CreateWebhookEndpoint", mock.Anything, mock.Anything)
+}
+
+func TestCreateWebhook_GeneratesSecret(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	mockRepo.On("CreateWebhookEndpoint", mock.Anything, mock.MatchedBy(func(e models.WebhookEndpoint) bool {
+		return strings.HasPrefix(e.Secret, "whsec_") && len(e.EventTypes) == 2 && *e.WalletID == activeWallet.ID
+	})).Return(models.WebhookEndpoint{ID: "w1", Secret: "whsec_x"}, nil)
+
+	_, err := usecase.CreateWebhook(context.Background(), models.CreateWebhookRequest{
+		WalletID:   activeWallet.ID,
+		URL:        "https://example.com/hooks",
+		EventTypes: []string{models.WebhookEventDeposit, models.WebhookEventHoldCaptured, models.WebhookEventDeposit},
+	})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCreateAPIKey_StoresHashOnly(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	var stored models.APIKey
+	mockRepo.On("CreateAPIKey", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(models.APIKey) }).
+		Return(models.APIKey{ID: "k1"}, nil)
+
+	_, err := usecase.CreateAPIKey(context.Background(), models.CreateAPIKeyRequest{
+		Name:   "partner",
+		Scopes: []string{models.ScopeBalanceRead, models.ScopeTransact, models.ScopeBalanceRead},
+		Role:   models.RoleFinance,
+	})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stored.Key, "wk_"))
+	assert.Equal(t, sha256Hex(stored.Key), stored.KeyHash)
+	assert.Equal(t, stored.Key[:len(stored.Prefix)], stored.Prefix)
+	assert.Equal(t, []string{models.ScopeBalanceRead, models.ScopeTransact}, stored.Scopes)
+	assert.Equal(t, models.RoleFinance, stored.Role)
+	assert.Nil(t, stored.ExpiresAt)
+}
+
+func TestCreateAPIKey_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	invalid := []models.CreateAPIKeyRequest{
+		{Scopes: []string{models.ScopeAdmin}},
+		{Name: "partner"},
+		{Name: "partner", Scopes: []string{"wallets:delete"}},
+		{Name: "partner", Scopes: []string{models.ScopeAdmin}, ExpiresIn: -1},
+		{Name: "partner", Scopes: []string{models.ScopeAdmin}},
+		{Name: "partner", Scopes: []string{models.ScopeAdmin}, Role: models.RoleCustomer},
+		{Name: "partner", Scopes: []string{models.ScopeAdmin}, Role: "superuser"},
+	}
+	for _, request := range invalid {
+		_, err := usecase.CreateAPIKey(context.Background(), request)
+		assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	}
+	mockRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	cases := []struct {
+		name string
+		key  models.APIKey
+		err  error
+		ok   bool
+	}{
+		{"active", models.APIKey{ID: "k1", Scopes: []string{models.ScopeTransact}}, nil, true},
+		{"revoked", models.APIKey{ID: "k1", RevokedAt: &past}, nil, false},
+		{"expired", models.APIKey{ID: "k1", ExpiresAt: &past}, nil, false},
+		{"unknown", models.APIKey{}, models.ErrAPIKeyNotFound, false},
+	}
+	for _, tc := range cases {
+		mockRepo := new(MockRepository)
+		usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+		mockRepo.On("GetAPIKeyByHash", mock.Anything, sha256Hex("wk_secret")).Return(tc.key, tc.err)
+
+		key, err := usecase.AuthenticateAPIKey(context.Background(), "wk_secret")
+		if tc.ok {
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.key.ID, key.ID, tc.name)
+		} else {
+			assert.ErrorIs(t, err, models.ErrUnauthorized, tc.name)
+		}
+	}
+}
+
+func TestRotateAPIKey_GracePeriod(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.New()
+	mockRepo.On("RotateAPIKey", mock.Anything, id, mock.Anything, mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Until(expiresAt) > 59*time.Minute && time.Until(expiresAt) <= time.Hour
+	})).Return(models.APIKey{ID: "k2", Key: "wk_new"}, nil)
+
+	res, err := usecase.RotateAPIKey(context.Background(), models.RotateAPIKeyRequest{KeyID: id.String(), GracePeriod: 3600})
+	assert.NoError(t, err)
+	assert.Equal(t, "wk_new", res.Key)
+	mockRepo.AssertExpectations(t)
+
+	_, err = usecase.RotateAPIKey(context.Background(), models.RotateAPIKeyRequest{KeyID: id.String(), GracePeriod: -1})
+	assert.ErrorIs(t, err, models.ErrInvalidRequest)
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	reader := models.APIKey{Scopes: []string{models.ScopeBalanceRead}}
+	admin := models.APIKey{Scopes: []string{models.ScopeAdmin}}
+
+	assert.True(t, reader.HasScope(models.ScopeBalanceRead))
+	assert.False(t, reader.HasScope(models.ScopeTransact))
+	assert.True(t, admin.HasScope(models.ScopeTransact))
+}
+
+func TestGetBalance_OwnedBySubject(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.MustParse(activeWallet.ID)
+	ctx := auth.WithSubject(context.Background(), "user-1")
+	mockRepo.On("IsWalletOwner", mock.Anything, id, "user-1").Return(true, nil)
+	mockRepo.On("GetBalance", mock.Anything, id).Return(models.GetBalanceResponse{Currency: "USD", Ledger: 100}, nil)
+
+	res, err := usecase.GetBalance(ctx, activeWallet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), res.Ledger)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetBalance_NotOwnedBySubject(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.MustParse(activeWallet.ID)
+	ctx := auth.WithSubject(context.Background(), "user-2")
+	mockRepo.On("IsWalletOwner", mock.Anything, id, "user-2").Return(false, nil)
+
+	_, err := usecase.GetBalance(ctx, activeWallet.ID)
+	assert.ErrorIs(t, err, models.ErrWalletNotFound)
+	mockRepo.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything)
+}
+
+func TestWalletTransaction_NotOwnedBySubject(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	id := uuid.MustParse(activeWallet.ID)
+	ctx := auth.WithSubject(context.Background(), "user-2")
+	mockRepo.On("IsWalletOwner", mock.Anything, id, "user-2").Return(false, nil)
+
+	_, err := usecase.WalletTransaction(ctx, models.WalletTransaction{WalletID: activeWallet.ID, Operation: "WITHDRAW", Amount: 100})
+	assert.ErrorIs(t, err, models.ErrWalletNotFound)
+	mockRepo.AssertNotCalled(t, "WalletTransactionWithdraw", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransfer_SourceNotOwnedBySubject(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	from, to := uuid.New(), uuid.New()
+	ctx := auth.WithSubject(context.Background(), "user-2")
+	mockRepo.On("IsWalletOwner", mock.Anything, from, "user-2").Return(false, nil)
+
+	_, err := usecase.Transfer(ctx, models.TransferRequest{FromWalletID: from.String(), ToWalletID: to.String(), Amount: 100})
+	assert.ErrorIs(t, err, models.ErrWalletNotFound)
+	mockRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddWalletOwner_InvalidSubject(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := usecase.NewUsecase(mockRepo, testRates, time.Minute)
+
+	for _, subject := range []string{"", strings.Repeat("s", 256)} {
+		_, err := usecase.AddWalletOwner(context.Background(), models.WalletOwnerRequest{WalletID: activeWallet.ID, Subject: subject})
+		assert.ErrorIs(t, err, models.ErrInvalidRequest)
+	}
+	mockRepo.AssertNotCalled(t, "AddWalletOwner", mock.Anything, mock.Anything, mock.Anything)
+}